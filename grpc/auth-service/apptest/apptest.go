@@ -0,0 +1,121 @@
+// Package apptest runs the auth service and its HTTP gateway in process for
+// integration tests.
+package apptest
+
+import (
+	"auth-service/internal/app"
+	"auth-service/internal/config"
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/ratelimit"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+type Options struct {
+	// Listener serves gRPC, usually a bufconn listener.
+	Listener net.Listener
+	// Target and DialOptions reach Listener; the gateway dials through them.
+	Target      string
+	DialOptions []grpc.DialOption
+	// UserTarget and UserDialOptions reach the user service.
+	UserTarget      string
+	UserDialOptions []grpc.DialOption
+	TracerProvider  oteltrace.TracerProvider
+	// MeterProvider records the service metrics, which are dropped when
+	// nil.
+	MeterProvider metric.MeterProvider
+	// LogOutput receives the service logs, which are discarded when nil.
+	LogOutput io.Writer
+}
+
+type Service struct {
+	app      *app.App
+	cancel   context.CancelFunc
+	done     chan error
+	httpAddr string
+}
+
+// Start wires the service like cmd/server.go does and serves it until Stop.
+// The gateway listens on a random local port, see HTTPAddr.
+func Start(ctx context.Context, opts Options) (*Service, error) {
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config.Config{
+		Swagger: config.SwaggerConfig{Host: httpListener.Addr().String()},
+		Clients: config.ClientsConfig{
+			UserService: grpcclient.Config{
+				Timeouts: grpcclient.TimeoutsConfig{Dial: 5 * time.Second, RPC: 5 * time.Second},
+			},
+		},
+		RateLimit: ratelimit.Config{
+			Enabled: true,
+			Backend: "memory",
+			Rules: []ratelimit.Rule{
+				{Method: "/auth.AuthService/Login", RequestsPerSecond: 1, Burst: 5, Key: "field:username"},
+				{Method: "/auth.AuthService/Login", RequestsPerSecond: 5, Burst: 20, Key: "peer"},
+				{Method: "/auth.AuthService/Register", RequestsPerSecond: 1, Burst: 5, Key: "peer"},
+			},
+		},
+		Idempotency: config.IdempotencyConfig{TTL: time.Hour},
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	a, err := app.NewApp(ctx,
+		app.WithConfig(cfg),
+		app.WithTracerProvider(opts.TracerProvider),
+		app.WithMeterProvider(meterProvider(opts)),
+		app.WithLogOutput(logOutput(opts)),
+		app.WithGRPCListener(opts.Listener),
+		app.WithHTTPListener(httpListener),
+		app.WithUserService(opts.UserTarget, opts.UserDialOptions...),
+		app.WithGatewayEndpoint(opts.Target, opts.DialOptions...),
+	)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &Service{app: a, cancel: cancel, done: make(chan error, 1), httpAddr: httpListener.Addr().String()}
+	go func() { s.done <- a.Run() }()
+
+	return s, nil
+}
+
+// HTTPAddr is the host:port of the gateway.
+func (s *Service) HTTPAddr() string {
+	return s.httpAddr
+}
+
+// Stop cancels the service, waits for Run to return and shuts it down.
+func (s *Service) Stop() error {
+	s.cancel()
+	if err := <-s.done; err != nil {
+		return err
+	}
+	return s.app.Shutdown()
+}
+
+func logOutput(opts Options) io.Writer {
+	if opts.LogOutput == nil {
+		return io.Discard
+	}
+	return opts.LogOutput
+}
+
+func meterProvider(opts Options) metric.MeterProvider {
+	if opts.MeterProvider == nil {
+		return noop.NewMeterProvider()
+	}
+	return opts.MeterProvider
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"auth-service/internal/config"
 	grpcservices "auth-service/internal/delivery/grpc"
 	"auth-service/internal/usecase"
 	"auth-service/pb"
@@ -12,50 +13,158 @@ import (
 	"net"
 	"net/http"
 	"os"
+	userpb "user-service/pb"
 
+	pkgconfig "common-service/pkg/config"
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/idempotency"
 	"common-service/pkg/logger"
+	"common-service/pkg/metrics"
+	"common-service/pkg/ratelimit"
+	"common-service/pkg/reqctx"
+	"common-service/pkg/server"
 	"common-service/pkg/trace"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type App struct {
 	ctx            context.Context
+	opts           options
 	grpcServer     *grpc.Server
+	gatewayLis     net.Listener
 	tp             *trace.Tracer
-	userGrpcClient pb.UserServiceClient
-	httpServer     *http.ServeMux
+	meter          *metrics.Meter
+	reloader       *pkgconfig.Reloader[config.Config]
+	grpcClient     *grpc.ClientConn
+	userGrpcClient userpb.UserServiceClient
+	swaggerHost    string
+
+	server.Listeners
+
+	httpServer *http.ServeMux
+	httpSrv    *http.Server
 }
 
-func NewApp(ctx context.Context) (*App, error) {
+func NewApp(ctx context.Context, opts ...Option) (*App, error) {
+	o := options{
+		gatewayDialOptions: []grpc.DialOption{grpc.WithInsecure()}, // disable TLS for local dev
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Load configuration
+	cfg := o.config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+			return nil, err
+		}
+	}
+
 	// logging
-	logger.InitLogger("debug")
+	if o.logOutput != nil {
+		logger.InitLoggerTo(cfg.App.LogLevel, o.logOutput)
+	} else {
+		logger.InitLogger(cfg.App.LogLevel)
+	}
+	cfg.LogEffective(slog.Default())
 
 	// tracing
-	tp, err := trace.InitTracer(ctx, "jaeger:4318", "AUTH_SERVICE")
+	var tp *trace.Tracer
+	tracerProvider := o.tracerProvider
+	if tracerProvider == nil {
+		var err error
+		tp, err = trace.InitTracer(ctx, cfg.App.Trace.Endpoint, "AUTH_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize tracer: %v", err)
+			return nil, err
+		}
+		tp.SetSampleRatio(cfg.App.Trace.Ratio())
+		tracerProvider = tp.TracerProvider
+	}
+
+	// metrics
+	var meter *metrics.Meter
+	meterProvider := o.meterProvider
+	if meterProvider == nil {
+		var err error
+		meter, err = metrics.InitMeter(ctx, cfg.App.Metrics, "AUTH_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize meter: %v", err)
+			return nil, err
+		}
+		meterProvider = meter.MeterProvider
+	}
+
+	// listeners
+	listeners, err := server.Listen(server.Listeners{GRPC: o.grpcListener, HTTP: o.httpListener}, cfg.GRPC, cfg.HTTP)
 	if err != nil {
-		log.Fatalf("Failed to initialize tracer: %v", err)
 		return nil, err
 	}
 
 	// grpc client
-	grpcClient, err := grpc.NewClient("user-service:50051",
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-	)
+	userCfg := cfg.Clients.UserService
+	if o.userTarget != "" {
+		userCfg.Target, userCfg.Port = o.userTarget, 0
+	}
+	userCfg.MeterProvider = meterProvider
+	grpcClient, userTimeout, err := grpcclient.NewClientWithTimeout(userCfg, append(o.userDialOptions, grpc.WithChainUnaryInterceptor(idempotency.ForwardKeyClientInterceptor()))...)
 	if err != nil {
 		log.Fatalf("Failed to create user grpc client: %v", err)
 	}
 
-	userGrpcClient := pb.NewUserServiceClient(grpcClient)
+	userGrpcClient := userpb.NewUserServiceClient(grpcClient)
+
+	// rate limiting
+	rateLimitCfg := cfg.RateLimit
+	rateLimitStore, err := ratelimit.NewStore(rateLimitCfg)
+	if err != nil {
+		log.Fatalf("Failed to create rate limit store: %v", err)
+	}
+	// Unless an endpoint is configured, the gateway dials a loopback listener
+	// of its own. Only calls arriving there carry an X-Forwarded-For set by
+	// the gateway; direct gRPC clients are keyed by their own address.
+	var (
+		gatewayListener net.Listener
+		forwardedTrust  ratelimit.ForwardedTrust
+	)
+	gatewayEndpoint := o.gatewayEndpoint
+	if gatewayEndpoint == "" {
+		gatewayEndpoint = cfg.Gateway.Endpoint
+	}
+	if gatewayEndpoint == "" {
+		gatewayListener, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("gateway listener: %w", err)
+		}
+		gatewayEndpoint = gatewayListener.Addr().String()
+		forwardedTrust = ratelimit.TrustLocalAddr(gatewayListener.Addr())
+	}
+	limiter, err := ratelimit.NewLimiter(rateLimitCfg, rateLimitStore, forwardedTrust)
+	if err != nil {
+		log.Fatalf("Failed to create rate limiter: %v", err)
+	}
 
 	// grpc server
 	grpcServer := grpc.NewServer(
-		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tracerProvider), otelgrpc.WithMeterProvider(meterProvider))),
+		grpc.ChainUnaryInterceptor(
+			reqctx.UnaryServerInterceptor(reqctx.DefaultPolicy()),
+			limiter.UnaryServerInterceptor(),
+			idempotency.UnaryServerInterceptor(idempotency.Options{
+				Store:   idempotency.NewMemoryStore(),
+				Methods: []string{pb.AuthService_Register_FullMethodName},
+				TTL:     cfg.Idempotency.TTL,
+				Lease:   cfg.Idempotency.Lease,
+			}),
+		),
 	)
 
 	// usecase
@@ -65,9 +174,12 @@ func NewApp(ctx context.Context) (*App, error) {
 	pb.RegisterAuthServiceServer(grpcServer, grpcservices.NewAuthService(userGrpcClient, authUsecase))
 
 	// grpc http gateway
-	gwMux := runtime.NewServeMux()
-	opts := []grpc.DialOption{grpc.WithInsecure()} // disable TLS for local dev
-	err = pb.RegisterAuthServiceHandlerFromEndpoint(ctx, gwMux, "localhost:50052", opts)
+	gwMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(idempotency.GatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(ratelimit.GatewayHeaderMatcher),
+		runtime.WithMetadata(reqctx.GatewayMetadata),
+	)
+	err = pb.RegisterAuthServiceHandlerFromEndpoint(ctx, gwMux, gatewayEndpoint, o.gatewayDialOptions)
 	if err != nil {
 		log.Fatalf("failed to start HTTP gateway: %v", err)
 	}
@@ -78,29 +190,56 @@ func NewApp(ctx context.Context) (*App, error) {
 	// Mount grpc-gateway at root
 	httpServer.Handle("/", gwMux)
 
+	// hot reload
+	reloader := cfg.NewReloader()
+	reloader.Subscribe("app.log_level", func(_, next *config.Config) {
+		logger.SetLevel(next.App.LogLevel)
+	})
+	if tp != nil {
+		reloader.Subscribe("app.trace.sample_ratio", func(_, next *config.Config) {
+			tp.SetSampleRatio(next.App.Trace.Ratio())
+		})
+	}
+	reloader.Subscribe("rate_limit.rules", func(_, next *config.Config) {
+		if err := limiter.SetRules(next.RateLimit.Rules); err != nil {
+			slog.Error("Keeping the current rate limits", "error", err)
+		}
+	})
+	reloader.Subscribe("clients.user_service.timeouts.rpc", func(_, next *config.Config) {
+		userTimeout.Set(next.Clients.UserService.Timeouts.RPC)
+	})
+
 	return &App{
 		ctx:            ctx,
+		opts:           o,
 		grpcServer:     grpcServer,
+		gatewayLis:     gatewayListener,
 		tp:             tp,
+		meter:          meter,
+		reloader:       reloader,
+		grpcClient:     grpcClient,
 		userGrpcClient: userGrpcClient,
+		swaggerHost:    cfg.Swagger.Host,
+		Listeners:      listeners,
 		httpServer:     httpServer,
 	}, nil
 }
 
 func (a *App) Run() error {
-	// Start server
-	port := ":50052"
-
-	fmt.Printf("gRPC server starting on port %s \n", port)
-	lis, err := net.Listen("tcp", port)
-	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
-	}
-
 	// Start gRPC server in a separate goroutine
 	go func() {
-		log.Fatal(a.grpcServer.Serve(lis))
+		fmt.Printf("gRPC server starting on %s \n", a.GRPCAddr())
+		if err := a.grpcServer.Serve(a.GRPC); err != nil {
+			log.Fatal(err)
+		}
 	}()
+	if a.gatewayLis != nil {
+		go func() {
+			if err := a.grpcServer.Serve(a.gatewayLis); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 
 	// health check endpoint
 	a.httpServer.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -133,8 +272,8 @@ func (a *App) Run() error {
 		}
 
 		// Simple string replace (for Swagger 2.0)
-		// Replace `"host": ""` with `"host": "auth-service:8080"`
-		fixed := bytes.ReplaceAll(data, []byte(`"host": ""`), []byte(`"host": "auth-service:8080"`))
+		// Replace `"host": ""` with the configured swagger.host
+		fixed := bytes.ReplaceAll(data, []byte(`"host": ""`), []byte(`"host": "`+a.swaggerHost+`"`))
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(fixed)
@@ -142,27 +281,50 @@ func (a *App) Run() error {
 
 	// Serve Swagger UI
 	a.httpServer.Handle("/swagger/", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8081/swagger.json"), // must point to your swagger.json
+		httpSwagger.URL("http://"+a.swaggerHost+"/auth/swagger.json"), // must point to your swagger.json
 	))
 
-	handlerWithCORS := withCORS(a.httpServer)
+	handlerWithCORS := withCORS(reqctx.Middleware(reqctx.DefaultPolicy(), a.httpServer))
 
 	// Start HTTP server in a separate goroutine
+	a.httpSrv = &http.Server{Handler: handlerWithCORS}
 	go func() {
-		httpPort := ":8081"
-		fmt.Printf("HTTP server starting on port %s \n", httpPort)
-		if err := http.ListenAndServe(httpPort, handlerWithCORS); err != nil {
+		fmt.Printf("HTTP server starting on %s \n", a.HTTPAddr())
+		if err := a.httpSrv.Serve(a.HTTP); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start HTTP server: %v", err)
 		}
 	}()
 
+	// apply config file changes until the context is done
+	go func() {
+		if err := a.reloader.Watch(a.ctx); err != nil {
+			slog.Error("Config watcher stopped", "error", err)
+		}
+	}()
+
 	<-a.ctx.Done()
 	return nil
 }
 
 func (a *App) Shutdown() error {
-	if err := a.tp.TracerProvider.Shutdown(context.Background()); err != nil {
-		slog.Error("Error shutting down tracer provider", "error", err)
+	if err := a.CloseHTTP(context.Background(), a.httpSrv); err != nil {
+		slog.Error("Error shutting down HTTP server", "error", err)
+	}
+	a.grpcServer.GracefulStop()
+	if err := a.grpcClient.Close(); err != nil {
+		slog.Error("Error closing user grpc client", "error", err)
+	}
+
+	// providers passed in through options belong to the caller
+	if a.tp != nil {
+		if err := a.tp.TracerProvider.Shutdown(context.Background()); err != nil {
+			slog.Error("Error shutting down tracer provider", "error", err)
+		}
+	}
+	if a.meter != nil {
+		if err := a.meter.Shutdown(context.Background()); err != nil {
+			slog.Error("Error shutting down meter provider", "error", err)
+		}
 	}
 	return nil
 }
@@ -173,7 +335,8 @@ func withCORS(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*") // allow all origins
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Baggage, X-Request-Id, X-Tenant-Id, X-Client-Version")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id")
 
 		// Handle preflight requests
 		if r.Method == http.MethodOptions {
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"auth-service/internal/config"
+	"auth-service/pb"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestApp_ServesGatewayAndSwagger(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // the logger writes logs/app.log
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api", "swagger"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "swagger", "auth.swagger.json"), []byte(`{"host": ""}`), 0o644))
+
+	cfg := &config.Config{
+		App:     config.AppConfig{Name: "auth-service"},
+		HTTP:    config.HTTPConfig{Host: "127.0.0.1"},
+		GRPC:    config.GRPCConfig{Host: "127.0.0.1"},
+		Swagger: config.SwaggerConfig{Host: "auth.example:8081"},
+		Clients: config.ClientsConfig{
+			UserService: grpcclient.Config{
+				Target:   "127.0.0.1",
+				Port:     1,
+				Timeouts: grpcclient.TimeoutsConfig{RPC: time.Second},
+			},
+		},
+		RateLimit: ratelimit.Config{
+			Enabled: true,
+			Backend: "memory",
+			Rules:   []ratelimit.Rule{{Method: "/auth.AuthService/Login", RequestsPerSecond: 0.001, Burst: 1, Key: "field:username"}},
+		},
+		Idempotency: config.IdempotencyConfig{TTL: time.Hour},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := NewApp(ctx, WithConfig(cfg), WithTracerProvider(noop.NewTracerProvider()), WithMeterProvider(metricnoop.NewMeterProvider()))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		assert.NoError(t, a.Shutdown())
+	})
+
+	base := "http://" + a.HTTPAddr()
+
+	res, err := http.Get(base + "/health")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Only the gRPC server rate limits, so a 429 on the second login shows
+	// the gateway dialed its listener without gateway.endpoint set.
+	login := func() int {
+		res, err := http.Post(base+"/v1/auth/login", "application/json", strings.NewReader(`{"username":"ada","password":"pw"}`))
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	login()
+	assert.Equal(t, http.StatusTooManyRequests, login())
+
+	res, err = http.Get(base + "/auth/swagger.json")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	var doc struct {
+		Host string `json:"host"`
+	}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "auth.example:8081", doc.Host)
+}
+
+func TestApp_TrustsForwardedForOnlyFromGateway(t *testing.T) {
+	t.Chdir(t.TempDir()) // the logger writes logs/app.log
+
+	cfg := &config.Config{
+		App:  config.AppConfig{Name: "auth-service"},
+		HTTP: config.HTTPConfig{Host: "127.0.0.1"},
+		GRPC: config.GRPCConfig{Host: "127.0.0.1"},
+		Clients: config.ClientsConfig{
+			UserService: grpcclient.Config{
+				Target:   "127.0.0.1",
+				Port:     1,
+				Timeouts: grpcclient.TimeoutsConfig{RPC: time.Second},
+			},
+		},
+		RateLimit: ratelimit.Config{
+			Enabled: true,
+			Backend: "memory",
+			Rules:   []ratelimit.Rule{{Method: "/auth.AuthService/Login", RequestsPerSecond: 0.001, Burst: 1, Key: "peer"}},
+			// the test client stands in for a load balancer
+			TrustedHops: 1,
+		},
+		Idempotency: config.IdempotencyConfig{TTL: time.Hour},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := NewApp(ctx, WithConfig(cfg), WithTracerProvider(noop.NewTracerProvider()), WithMeterProvider(metricnoop.NewMeterProvider()))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		assert.NoError(t, a.Shutdown())
+	})
+
+	// over HTTP every forwarded client gets its own bucket
+	login := func(forwardedFor string) int {
+		req, err := http.NewRequest(http.MethodPost, "http://"+a.HTTPAddr()+"/v1/auth/login", strings.NewReader(`{"username":"ada","password":"pw"}`))
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	login("203.0.113.1")
+	assert.NotEqual(t, http.StatusTooManyRequests, login("203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, login("203.0.113.1"))
+
+	// a direct gRPC client is keyed by its address whatever it forwards
+	conn, err := grpc.NewClient(a.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewAuthServiceClient(conn)
+	grpcLogin := func(forwardedFor string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-forwarded-for", forwardedFor)
+		_, err := client.Login(ctx, &pb.LoginRequest{Username: "ada", Password: "pw"})
+		return err
+	}
+	grpcLogin("198.51.100.1, 10.0.0.1")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(grpcLogin("198.51.100.2, 10.0.0.1")))
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"auth-service/internal/config"
+	"io"
+	"net"
+
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Option changes how NewApp wires the service. Without options the service
+// runs as deployed; the options let tests run it in process.
+type Option func(*options)
+
+type options struct {
+	config             *config.Config
+	tracerProvider     oteltrace.TracerProvider
+	meterProvider      metric.MeterProvider
+	grpcListener       net.Listener
+	httpListener       net.Listener
+	logOutput          io.Writer
+	userTarget         string
+	userDialOptions    []grpc.DialOption
+	gatewayEndpoint    string
+	gatewayDialOptions []grpc.DialOption
+}
+
+// WithConfig uses cfg as is instead of loading and validating the config
+// files.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) { o.config = cfg }
+}
+
+// WithTracerProvider instruments the service with tp instead of setting up
+// an OTLP exporter. The caller owns tp.
+func WithTracerProvider(tp oteltrace.TracerProvider) Option {
+	return func(o *options) { o.tracerProvider = tp }
+}
+
+// WithMeterProvider records the service metrics with mp instead of setting
+// up an OTLP exporter. The caller owns mp.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) { o.meterProvider = mp }
+}
+
+// WithLogOutput sends the service logs to w instead of stdout and
+// logs/app.log.
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) { o.logOutput = w }
+}
+
+func WithGRPCListener(lis net.Listener) Option {
+	return func(o *options) { o.grpcListener = lis }
+}
+
+func WithHTTPListener(lis net.Listener) Option {
+	return func(o *options) { o.httpListener = lis }
+}
+
+// WithUserService points the user service client at target, overriding
+// clients.user_service.target and port, with extra dial options, e.g. a
+// bufconn dialer.
+func WithUserService(target string, opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.userTarget = target
+		o.userDialOptions = append(o.userDialOptions, opts...)
+	}
+}
+
+// WithGatewayEndpoint sets how the HTTP gateway reaches this service's gRPC
+// server, overriding gateway.endpoint. opts replace the default insecure
+// transport.
+func WithGatewayEndpoint(target string, opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.gatewayEndpoint = target
+		o.gatewayDialOptions = opts
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"log/slog"
+	"time"
+
+	pkgconfig "common-service/pkg/config"
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/metrics"
+	"common-service/pkg/ratelimit"
+	"common-service/pkg/server"
+	"common-service/pkg/trace"
+)
+
+// EnvPrefix namespaces environment overrides, e.g. AUTH_SERVICE_HTTP_PORT.
+const EnvPrefix = "AUTH_SERVICE"
+
+type Config struct {
+	App         AppConfig         `mapstructure:"app"`
+	HTTP        HTTPConfig        `mapstructure:"http"`
+	GRPC        GRPCConfig        `mapstructure:"grpc"`
+	Gateway     GatewayConfig     `mapstructure:"gateway"`
+	Swagger     SwaggerConfig     `mapstructure:"swagger"`
+	Clients     ClientsConfig     `mapstructure:"clients"`
+	RateLimit   ratelimit.Config  `mapstructure:"rate_limit"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+
+	source pkgconfig.Source
+}
+
+type AppConfig struct {
+	Name     string        `mapstructure:"name" validate:"required"`
+	LogLevel string        `mapstructure:"log_level" validate:"omitempty,oneof=debug info warn error"`
+	Trace    TraceConfig   `mapstructure:"trace"`
+	Metrics  MetricsConfig `mapstructure:"metrics"`
+}
+
+type TraceConfig = trace.Config
+
+type MetricsConfig = metrics.Config
+
+type (
+	HTTPConfig = server.Config
+	GRPCConfig = server.Config
+)
+
+type GatewayConfig struct {
+	// Endpoint is how the HTTP gateway dials this service's gRPC server.
+	// Empty gives the gateway a loopback listener of its own, and only calls
+	// arriving there are rate limited by their X-Forwarded-For. With an
+	// endpoint, every call is limited by its transport peer.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+type SwaggerConfig struct {
+	// Host replaces the empty host of the served swagger.json, so "try it
+	// out" requests from the swagger UI reach the gateway.
+	Host string `mapstructure:"host"`
+}
+
+type ClientsConfig struct {
+	UserService grpcclient.Config `mapstructure:"user_service"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl" validate:"omitempty,min=1s"`
+	// Lease is how long an unfinished call holds its key before another
+	// call may take it over.
+	Lease time.Duration `mapstructure:"lease" validate:"omitempty,min=1s"`
+}
+
+// Load reads configs/base.yaml, the APP_ENV profile and local overrides,
+// applies AUTH_SERVICE_* env vars and validates the result.
+func Load() (*Config, error) {
+	var cfg Config
+	src, err := pkgconfig.Load(&cfg, pkgconfig.Options{EnvPrefix: EnvPrefix})
+	if err != nil {
+		return nil, err
+	}
+	cfg.source = src
+
+	return &cfg, nil
+}
+
+// NewReloader returns a reloader starting from c that loads the config
+// again when its files change, see pkgconfig.Reloader.
+func (c *Config) NewReloader() *pkgconfig.Reloader[Config] {
+	return pkgconfig.NewReloader(c, c.source, Load)
+}
+
+// LogEffective logs the config with secrets redacted.
+func (c *Config) LogEffective(logger *slog.Logger) {
+	pkgconfig.LogEffective(logger, c.source, c)
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoad_Profiles(t *testing.T) {
+	t.Chdir("../..")
+
+	tests := []struct {
+		env     string
+		traceEP string
+		userTgt string
+	}{
+		{env: "dev", traceEP: "localhost:4318", userTgt: "localhost"},
+		{env: "prod", traceEP: "jaeger:4318", userTgt: "user-service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.env)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.App.Name != "auth-service" {
+				t.Errorf("app.name = %q", cfg.App.Name)
+			}
+			if cfg.App.Trace.Endpoint != tt.traceEP {
+				t.Errorf("app.trace.endpoint = %q, want %q", cfg.App.Trace.Endpoint, tt.traceEP)
+			}
+			if cfg.Clients.UserService.Address() != tt.userTgt+":50051" {
+				t.Errorf("clients.user_service = %q, want %s:50051", cfg.Clients.UserService.Address(), tt.userTgt)
+			}
+			if cfg.GRPC.Address() != "0.0.0.0:50052" || cfg.HTTP.Address() != "0.0.0.0:8081" {
+				t.Errorf("listeners = %s, %s", cfg.GRPC.Address(), cfg.HTTP.Address())
+			}
+			if len(cfg.RateLimit.Rules) != 3 {
+				t.Errorf("rate_limit.rules = %+v", cfg.RateLimit.Rules)
+			}
+		})
+	}
+}
+
+func TestLoad_EnvOverride(t *testing.T) {
+	t.Chdir("../..")
+	t.Setenv("AUTH_SERVICE_GATEWAY_ENDPOINT", "auth-service:50052")
+	t.Setenv("AUTH_SERVICE_SWAGGER_HOST", "api.example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Gateway.Endpoint != "auth-service:50052" {
+		t.Errorf("gateway.endpoint = %q", cfg.Gateway.Endpoint)
+	}
+	if cfg.Swagger.Host != "api.example.com" {
+		t.Errorf("swagger.host = %q", cfg.Swagger.Host)
+	}
+}
+
+func TestLoad_InvalidPort(t *testing.T) {
+	t.Chdir("../..")
+	t.Setenv("AUTH_SERVICE_HTTP_PORT", "70000")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+// useConfigsCopy runs the test in a temp dir holding a copy of configs/.
+func useConfigsCopy(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.CopyFS(filepath.Join(dir, "configs"), os.DirFS("../../configs")); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	return filepath.Join(dir, "configs")
+}
+
+func TestNewReloader_AppliesSections(t *testing.T) {
+	configs := useConfigsCopy(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	r := cfg.NewReloader()
+
+	var rules []string
+	var timeout time.Duration
+	r.Subscribe("rate_limit.rules", func(_, next *Config) {
+		for _, rule := range next.RateLimit.Rules {
+			rules = append(rules, rule.Method)
+		}
+	})
+	r.Subscribe("clients.*.timeouts", func(_, next *Config) { timeout = next.Clients.UserService.Timeouts.RPC })
+	r.Subscribe("http", func(_, _ *Config) { t.Error("http did not change") })
+
+	local := filepath.Join(configs, "dev.local.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(local, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+clients:
+  user_service:
+    timeouts:
+      rpc: 5s
+rate_limit:
+  rules:
+    - method: "*"
+      requests_per_second: 10
+      key: peer
+`)
+	changed, err := r.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if !slices.Equal(changed, []string{"clients.user_service.timeouts.rpc", "rate_limit.rules"}) {
+		t.Errorf("changed = %v", changed)
+	}
+	if !slices.Equal(rules, []string{"*"}) || timeout != 5*time.Second {
+		t.Errorf("rules = %v, timeout = %v", rules, timeout)
+	}
+
+	write("rate_limit:\n  rules:\n    - method: \"*\"\n      requests_per_second: 10\n      key: cookie\n")
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("expected the unknown rate limit key to be rejected")
+	}
+	if got := r.Current().Clients.UserService.Timeouts.RPC; got != 5*time.Second {
+		t.Errorf("timeout = %v, want the last valid config kept", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"auth-service/internal/domain"
 	"auth-service/pb"
 	"context"
+	userpb "user-service/pb"
 )
 
 type authService struct {
@@ -11,7 +12,7 @@ type authService struct {
 	authUsecase domain.AuthUsecase
 }
 
-func NewAuthService(userGrpcClient pb.UserServiceClient, authUsecase domain.AuthUsecase) *authService {
+func NewAuthService(userGrpcClient userpb.UserServiceClient, authUsecase domain.AuthUsecase) *authService {
 	return &authService{authUsecase: authUsecase}
 }
 
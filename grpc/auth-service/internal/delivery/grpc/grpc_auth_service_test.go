@@ -0,0 +1,57 @@
+package grpcservices
+
+import (
+	"auth-service/pb"
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type fakeAuthUsecase struct{}
+
+func (u *fakeAuthUsecase) Login(ctx context.Context) (string, error) {
+	_, span := trace.StartSpan(ctx, "AuthUsecase.Login")
+	defer span.End()
+
+	return "token", nil
+}
+
+func (u *fakeAuthUsecase) Register(ctx context.Context) error {
+	_, span := trace.StartSpan(ctx, "AuthUsecase.Register")
+	defer span.End()
+
+	return nil
+}
+
+func newAuthClient(t *testing.T, rec *tracetest.Recorder) pb.AuthServiceClient {
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		pb.RegisterAuthServiceServer(s, NewAuthService(nil, &fakeAuthUsecase{}))
+	})
+	return pb.NewAuthServiceClient(conn)
+}
+
+func TestAuthService_Login(t *testing.T) {
+	rec := tracetest.Install(t)
+
+	if _, err := newAuthClient(t, rec).Login(context.Background(), &pb.LoginRequest{Username: "test"}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	rec.ServerSpan(t, "auth.AuthService/Login").
+		HasParent("auth.AuthService/Login").
+		HasChild("AuthUsecase.Login")
+}
+
+func TestAuthService_Register(t *testing.T) {
+	rec := tracetest.Install(t)
+
+	if _, err := newAuthClient(t, rec).Register(context.Background(), &pb.RegisterUserRequest{Username: "test"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	rec.ServerSpan(t, "auth.AuthService/Register").
+		HasChild("AuthUsecase.Register")
+}
@@ -1,10 +1,10 @@
 package usecase
 
 import (
-	"auth-service/pb"
 	"common-service/pkg/trace"
 	"context"
 	"fmt"
+	"user-service/pb"
 )
 
 type authUsecase struct {
@@ -15,9 +15,9 @@ func NewAuthUsecase(userGrpcClient pb.UserServiceClient) *authUsecase {
 	return &authUsecase{UserGrpcClient: userGrpcClient}
 }
 
-func (a *authUsecase) Login(ctx context.Context) (string, error) {
+func (a *authUsecase) Login(ctx context.Context) (token string, err error) {
 	ctx, span := trace.StartSpan(ctx, "AuthUsecase.Login")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
 	user, err := a.UserGrpcClient.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: "test@test.com"})
 	if err != nil {
@@ -27,9 +27,9 @@ func (a *authUsecase) Login(ctx context.Context) (string, error) {
 	return "", nil
 }
 
-func (a *authUsecase) Register(ctx context.Context) error {
+func (a *authUsecase) Register(ctx context.Context) (err error) {
 	ctx, span := trace.StartSpan(ctx, "AuthUsecase.Register")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
 	user, err := a.UserGrpcClient.CreateUser(ctx, &pb.RegisterRequest{
 		Username: "test",
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"auth-service/internal/mocks"
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"testing"
+	"user-service/pb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+type fakeUserServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (s *fakeUserServer) CreateUser(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	return &pb.RegisterResponse{Success: true}, nil
+}
+
+func (s *fakeUserServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.ApiResponse, error) {
+	return &pb.ApiResponse{Success: true}, nil
+}
+
+func newUserClient(t *testing.T, rec *tracetest.Recorder) pb.UserServiceClient {
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		pb.RegisterUserServiceServer(s, &fakeUserServer{})
+	})
+	return pb.NewUserServiceClient(conn)
+}
+
+func TestAuthUsecase_Login_Table(t *testing.T) {
+	errNotFound := errors.New("user not found")
+
+	tests := []struct {
+		name    string
+		resp    *pb.ApiResponse
+		rpcErr  error
+		wantErr error
+	}{
+		{
+			name: "user found",
+			resp: &pb.ApiResponse{Success: true},
+		},
+		{
+			name:    "user service error",
+			rpcErr:  errNotFound,
+			wantErr: errNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mocks.NewMockUserServiceClient(t)
+			client.EXPECT().
+				GetUserByEmail(mock.Anything, &pb.GetUserByEmailRequest{Email: "test@test.com"}).
+				Return(tt.resp, tt.rpcErr)
+
+			_, err := NewAuthUsecase(client).Login(context.Background())
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthUsecase_Register_Table(t *testing.T) {
+	errUnavailable := errors.New("user service unavailable")
+
+	tests := []struct {
+		name    string
+		resp    *pb.RegisterResponse
+		rpcErr  error
+		wantErr error
+	}{
+		{
+			name: "user created",
+			resp: &pb.RegisterResponse{Success: true},
+		},
+		{
+			name:    "user service error",
+			rpcErr:  errUnavailable,
+			wantErr: errUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mocks.NewMockUserServiceClient(t)
+			client.EXPECT().
+				CreateUser(mock.Anything, &pb.RegisterRequest{Username: "test", Password: "test"}).
+				Return(tt.resp, tt.rpcErr)
+
+			err := NewAuthUsecase(client).Register(context.Background())
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthUsecase_Login(t *testing.T) {
+	rec := tracetest.Install(t)
+	uc := NewAuthUsecase(newUserClient(t, rec))
+
+	if _, err := uc.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	rec.Span(t, "AuthUsecase.Login").
+		IsRoot().
+		HasClientSpan("user.UserService/GetUserByEmail")
+}
+
+func TestAuthUsecase_Register(t *testing.T) {
+	rec := tracetest.Install(t)
+	uc := NewAuthUsecase(newUserClient(t, rec))
+
+	if err := uc.Register(context.Background()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	rec.Span(t, "AuthUsecase.Register").
+		IsRoot().
+		HasClientSpan("user.UserService/CreateUser")
+	rec.ServerSpan(t, "user.UserService/CreateUser").
+		HasParent("user.UserService/CreateUser")
+}
@@ -0,0 +1,190 @@
+// Package config loads a service configuration from layered YAML files and
+// environment variables:
+//
+//  1. configs/base.yaml, shared by every environment
+//  2. configs/<env>.yaml, where env comes from APP_ENV and defaults to dev
+//  3. configs/local.yaml and configs/<env>.local.yaml, untracked overrides
+//     for a developer machine
+//  4. environment variables named after the key under a service prefix, so
+//     with the prefix USER_SERVICE, USER_SERVICE_HTTP_PORT sets http.port
+//
+// Later layers win. String values may then be secret references, such as
+// file:///run/secrets/pg_password or env:PG_PASSWORD, which are replaced by
+// the secret they point at, see Secrets. The result is validated against
+// the validate struct tags of the target, see Validate.
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnvVar selects the environment profile.
+	EnvVar     = "APP_ENV"
+	defaultEnv = "dev"
+)
+
+type Options struct {
+	// EnvPrefix namespaces environment overrides, e.g. USER_SERVICE.
+	EnvPrefix string
+	// Env selects the profile. Empty means $APP_ENV, then dev.
+	Env string
+	// Paths are searched in order for each file. Empty means ./configs
+	// then the working directory.
+	Paths []string
+	// Secrets resolves secret references. Nil means DefaultSecrets.
+	Secrets *Secrets
+}
+
+// Source describes where a loaded configuration came from.
+type Source struct {
+	Env       string
+	Files     []string
+	EnvPrefix string
+	// Secrets maps the dotted keys of values that were resolved from secret
+	// references to those references.
+	Secrets map[string]string
+
+	secrets  *Secrets
+	resolved map[string]string
+	paths    []string
+}
+
+// Load fills cfg, a pointer to a struct with mapstructure tags, from the
+// layers described in the package doc, resolves its secret references and
+// validates it. The profile file must exist; the base and local files are
+// optional.
+func Load(cfg any, opts Options) (Source, error) {
+	env := opts.Env
+	if env == "" {
+		env = os.Getenv(EnvVar)
+	}
+	if env == "" {
+		env = defaultEnv
+	}
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"./configs", "."}
+	}
+	src := Source{Env: env, EnvPrefix: opts.EnvPrefix, paths: paths}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	for _, name := range []string{"base", env, "local", env + ".local"} {
+		file := find(paths, name+".yaml")
+		if file == "" {
+			if name == env {
+				return src, fmt.Errorf("config profile %q: %s.yaml not found in %s", env, env, strings.Join(paths, ", "))
+			}
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return src, fmt.Errorf("read config %s: %w", file, err)
+		}
+		if err := v.MergeConfig(bytes.NewReader(data)); err != nil {
+			return src, fmt.Errorf("parse config %s: %w", file, err)
+		}
+		src.Files = append(src.Files, file)
+	}
+
+	if opts.EnvPrefix != "" {
+		v.SetEnvPrefix(opts.EnvPrefix)
+	}
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// AutomaticEnv only applies to keys viper already knows, so bind every
+	// field to allow setting keys that no file mentions.
+	for _, key := range keys(reflect.TypeOf(cfg), "") {
+		if err := v.BindEnv(key); err != nil {
+			return src, fmt.Errorf("bind env for %s: %w", key, err)
+		}
+	}
+
+	if err := v.Unmarshal(cfg); err != nil {
+		return src, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	src.secrets = opts.Secrets
+	if src.secrets == nil {
+		src.secrets = DefaultSecrets()
+	}
+	refs, values, err := resolveSecrets(context.Background(), src.secrets, cfg)
+	if err != nil {
+		return src, err
+	}
+	src.Secrets, src.resolved = refs, values
+
+	return src, Validate(cfg)
+}
+
+func find(paths []string, name string) string {
+	for _, dir := range paths {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+	return ""
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// keys lists the dotted mapstructure keys of the leaf fields of t.
+func keys(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Struct && ft != durationType {
+			out = append(out, keys(ft, key)...)
+			continue
+		}
+		out = append(out, key)
+	}
+	return out
+}
+
+// fieldName returns the mapstructure name of an exported field.
+func fieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		return strings.ToLower(f.Name), true
+	}
+	return tag, true
+}
+
+// errNotStruct is returned for targets that are not a struct pointer.
+var errNotStruct = errors.New("config: target must be a pointer to a struct")
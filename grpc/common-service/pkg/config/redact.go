@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// secretNames are key fragments whose values are always masked.
+var secretNames = []string{"password", "secret", "token", "api_key", "apikey", "private_key", "credentials"}
+
+// dsnPassword matches the password of a key=value DSN.
+var dsnPassword = regexp.MustCompile(`(?i)(password=)\S+`)
+
+// Redact returns cfg as nested maps keyed like the config files, with
+// secrets masked: fields tagged secret:"true", fields whose key names a
+// secret, and passwords embedded in URLs or DSNs.
+func Redact(cfg any) map[string]any {
+	return redact(cfg, nil)
+}
+
+// redact is Redact that also masks the dotted keys in extra, e.g. values
+// resolved from secret references.
+func redact(cfg any, extra map[string]string) map[string]any {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return redactStruct(v, "", extra)
+}
+
+// LogEffective logs the redacted configuration and where it came from.
+// Values resolved from secret references are always redacted.
+func LogEffective(logger *slog.Logger, src Source, cfg any) {
+	logger.Info("Effective config",
+		"env", src.Env,
+		"files", src.Files,
+		"env_prefix", src.EnvPrefix,
+		"secrets", src.Secrets,
+		"config", redact(cfg, src.Secrets),
+	)
+}
+
+func redactStruct(v reflect.Value, prefix string, extra map[string]string) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		key := join(prefix, name)
+		fv := v.Field(i)
+		_, resolved := extra[key]
+		if (f.Tag.Get("secret") == "true" || isSecretName(name) || resolved) && !fv.IsZero() {
+			out[name] = redacted
+			continue
+		}
+		out[name] = redactValue(fv, key, extra)
+	}
+	return out
+}
+
+func redactValue(v reflect.Value, key string, extra map[string]string) any {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Struct:
+		return redactStruct(v, key, extra)
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), key, extra)
+	case v.Kind() == reflect.String:
+		return redactString(v.String())
+	case v.Kind() == reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = redactValue(v.Index(i), key, extra)
+		}
+		return items
+	case v.Kind() == reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := fmt.Sprint(iter.Key().Interface())
+			if isSecretName(name) {
+				m[name] = redacted
+				continue
+			}
+			m[name] = redactValue(iter.Value(), join(key, name), extra)
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+func redactString(s string) string {
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				return strings.Replace(s, u.User.String()+"@", u.User.Username()+":"+redacted+"@", 1)
+			}
+		}
+	}
+	return dsnPassword.ReplaceAllString(s, "${1}"+redacted)
+}
+
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
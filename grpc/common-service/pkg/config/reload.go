@@ -0,0 +1,210 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the several events an editor or a ConfigMap update
+// produces for one change.
+const reloadDebounce = 100 * time.Millisecond
+
+// Reloader holds the current configuration and replaces it when its files
+// change. A reload loads every layer again and is only applied when the
+// result is valid. The new config is then swapped in whole, so Current
+// never returns a half-applied config, and subscribers of the sections that
+// changed are called.
+type Reloader[T any] struct {
+	src     Source
+	load    func() (*T, error)
+	current atomic.Pointer[T]
+
+	mu   sync.Mutex // serializes reloads and guards subs
+	subs map[int]subscription[T]
+	next int
+}
+
+type subscription[T any] struct {
+	pattern []string
+	fn      func(old, new *T)
+}
+
+// NewReloader starts from cfg, loaded from src, and reloads with load,
+// usually the service's config.Load.
+func NewReloader[T any](cfg *T, src Source, load func() (*T, error)) *Reloader[T] {
+	r := &Reloader[T]{src: src, load: load, subs: make(map[int]subscription[T])}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the config in effect. It must not be modified.
+func (r *Reloader[T]) Current() *T {
+	return r.current.Load()
+}
+
+// Subscribe calls fn after a reload that changed a key matching pattern. A
+// pattern is a dotted key where * matches one segment and which also
+// matches the keys below it: app.log_level, app.trace or clients.*.timeouts.
+// Subscribers run one at a time on the reloading goroutine and must not
+// call Subscribe or Reload. The returned function cancels the subscription.
+func (r *Reloader[T]) Subscribe(pattern string, fn func(old, new *T)) (cancel func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.next
+	r.next++
+	r.subs[id] = subscription[T]{pattern: strings.Split(pattern, "."), fn: fn}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subs, id)
+	}
+}
+
+// Reload loads the config again and applies it if it is valid and differs
+// from the current one. It returns the keys that changed, and logs a
+// warning listing those no subscriber applies, which only take effect on a
+// restart.
+func (r *Reloader[T]) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	old := r.current.Load()
+	changed := changedKeys(reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem(), "")
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	r.current.Store(next)
+
+	ids := make([]int, 0, len(r.subs))
+	for id := range r.subs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		sub := r.subs[id]
+		if slices.ContainsFunc(changed, func(key string) bool { return matchKey(sub.pattern, key) }) {
+			sub.fn(old, next)
+		}
+	}
+
+	// nothing applies these until the service restarts
+	var pending []string
+	for _, key := range changed {
+		if !slices.ContainsFunc(ids, func(id int) bool { return matchKey(r.subs[id].pattern, key) }) {
+			pending = append(pending, key)
+		}
+	}
+	if len(pending) > 0 {
+		slog.Warn("Config changes take effect on the next restart", "keys", pending)
+	}
+	return changed, nil
+}
+
+// Watch reloads whenever one of the config files of the profile is
+// written, created or replaced, until ctx is done. Rejected configs are
+// logged and the current one stays in effect. A config that was not loaded
+// from files is not watched.
+func (r *Reloader[T]) Watch(ctx context.Context) error {
+	if len(r.src.paths) == 0 {
+		return nil
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch config: %w", err)
+	}
+	defer w.Close()
+
+	// watch directories rather than files, editors and ConfigMap updates
+	// replace files instead of writing them
+	watched := 0
+	for _, dir := range r.src.paths {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("watch config dir %s: %w", dir, err)
+		}
+		watched++
+	}
+	if watched == 0 {
+		return nil
+	}
+
+	names := []string{"base.yaml", r.src.Env + ".yaml", "local.yaml", r.src.Env + ".local.yaml", "..data"}
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case ev := <-w.Events:
+			if slices.Contains(names, filepath.Base(ev.Name)) && !ev.Has(fsnotify.Chmod) {
+				timer.Reset(reloadDebounce)
+			}
+		case err := <-w.Errors:
+			slog.Warn("Config watcher failed", "error", err)
+		case <-timer.C:
+			changed, err := r.Reload()
+			if err != nil {
+				slog.Error("Config reload rejected, keeping the current config", "error", err)
+				continue
+			}
+			if len(changed) > 0 {
+				slog.Info("Config reloaded", "changed", changed)
+			}
+		}
+	}
+}
+
+// changedKeys lists the dotted keys of the leaves that differ between a
+// and b. Slices and maps are compared whole.
+func changedKeys(a, b reflect.Value, prefix string) []string {
+	if a.Kind() == reflect.Struct && a.Type() != durationType {
+		var out []string
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := fieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			out = append(out, changedKeys(a.Field(i), b.Field(i), join(prefix, name))...)
+		}
+		return out
+	}
+	if reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return nil
+	}
+	return []string{prefix}
+}
+
+func matchKey(pattern []string, key string) bool {
+	segments := strings.Split(key, ".")
+	if len(pattern) > len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReloader(t *testing.T, dir string) *Reloader[testConfig] {
+	t.Helper()
+	load := func() (*testConfig, error) {
+		var cfg testConfig
+		_, err := Load(&cfg, Options{Paths: []string{dir}})
+		return &cfg, err
+	}
+	var cfg testConfig
+	src, err := Load(&cfg, Options{Paths: []string{dir}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return NewReloader(&cfg, src, load)
+}
+
+func TestReloader_Reload(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"base.yaml": baseYAML, "dev.yaml": "http:\n  port: 8081\n"})
+	r := newTestReloader(t, dir)
+	initial := r.Current()
+
+	var calls []string
+	r.Subscribe("app.log_level", func(old, new *testConfig) {
+		calls = append(calls, "log_level "+old.App.LogLevel+"->"+new.App.LogLevel)
+	})
+	r.Subscribe("http", func(_, new *testConfig) { calls = append(calls, "http") })
+	cancel := r.Subscribe("*.name", func(_, _ *testConfig) { calls = append(calls, "name") })
+	cancel()
+
+	if err := os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("app:\n  log_level: debug\n  name: renamed\nhttp:\n  port: 8081\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := r.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if !slices.Equal(changed, []string{"app.name", "app.log_level"}) {
+		t.Errorf("changed = %v", changed)
+	}
+	if !slices.Equal(calls, []string{"log_level info->debug"}) {
+		t.Errorf("calls = %v", calls)
+	}
+	if r.Current().App.LogLevel != "debug" || initial.App.LogLevel != "info" {
+		t.Errorf("current = %+v, initial = %+v; want a new config swapped in", r.Current().App, initial.App)
+	}
+}
+
+func TestReloader_Reload_LogsChangesNeedingRestart(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	dir := writeFiles(t, map[string]string{"base.yaml": baseYAML, "dev.yaml": "http:\n  port: 8081\n"})
+	r := newTestReloader(t, dir)
+	r.Subscribe("app.log_level", func(_, _ *testConfig) {})
+
+	if err := os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("app:\n  log_level: debug\nhttp:\n  port: 8082\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	got := logs.String()
+	if !strings.Contains(got, `"msg":"Config changes take effect on the next restart","keys":["http.port"]`) {
+		t.Errorf("logs = %s, want a warning for http.port only", got)
+	}
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"base.yaml": baseYAML, "dev.yaml": "http:\n  port: 8081\n"})
+	r := newTestReloader(t, dir)
+	r.Subscribe("http", func(_, _ *testConfig) { t.Error("subscriber called for a rejected config") })
+
+	if err := os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("http:\n  port: 70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	if r.Current().HTTP.Port != 8081 {
+		t.Errorf("port = %d, want the old config kept", r.Current().HTTP.Port)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"base.yaml": baseYAML, "dev.yaml": "http:\n  port: 8081\n"})
+	r := newTestReloader(t, dir)
+
+	levels := make(chan string, 1)
+	r.Subscribe("app.log_level", func(_, new *testConfig) { levels <- new.App.LogLevel })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Watch(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+
+	// replace the file the way editors and ConfigMap updates do
+	deadline := time.After(5 * time.Second)
+	for {
+		tmp := filepath.Join(dir, "dev.yaml.tmp")
+		if err := os.WriteFile(tmp, []byte("app:\n  log_level: warn\nhttp:\n  port: 8081\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, "dev.yaml")); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case level := <-levels:
+			if level != "warn" {
+				t.Errorf("log_level = %q, want warn", level)
+			}
+			return
+		case <-time.After(300 * time.Millisecond):
+			// the watcher may not have been started yet
+		case <-deadline:
+			t.Fatal("change not picked up")
+		}
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"app.log_level", "app.log_level", true},
+		{"app.trace", "app.trace.sample_ratio", true},
+		{"clients.*", "clients.user_service.timeouts.rpc", true},
+		{"clients.*.timeouts", "clients.user_service.timeouts.rpc", true},
+		{"clients.*.timeouts", "clients.user_service.retry.max_attempts", false},
+		{"app.trace.endpoint", "app.trace", false},
+		{"app", "application.name", false},
+	}
+	for _, tt := range tests {
+		if got := matchKey(strings.Split(tt.pattern, "."), tt.key); got != tt.want {
+			t.Errorf("matchKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SecretProvider resolves a secret reference such as env:PG_PASSWORD to its
+// value. The reference is passed whole, scheme included.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider.
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Secrets resolves references by their scheme, the part before the first
+// colon. A string config value is a reference when its scheme is registered,
+// so URIs like postgres://... or host:port values are left alone.
+type Secrets struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecrets returns a resolver without providers.
+func NewSecrets() *Secrets {
+	return &Secrets{providers: make(map[string]SecretProvider)}
+}
+
+// DefaultSecrets resolves file:// and env: references, and vault://
+// references when VAULT_ADDR is set, authenticating with VAULT_TOKEN.
+func DefaultSecrets() *Secrets {
+	s := NewSecrets()
+	s.Register("file", SecretProviderFunc(resolveFile))
+	s.Register("env", SecretProviderFunc(resolveEnv))
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		s.Register("vault", NewVaultProvider(addr, os.Getenv("VAULT_TOKEN")))
+	}
+	return s
+}
+
+// Register makes p resolve references with the given scheme.
+func (s *Secrets) Register(scheme string, p SecretProvider) {
+	s.providers[scheme] = p
+}
+
+// IsRef reports whether value is a reference a provider is registered for.
+func (s *Secrets) IsRef(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return false
+	}
+	_, ok = s.providers[scheme]
+	return ok
+}
+
+// Resolve returns the value ref points at.
+func (s *Secrets) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, _, _ := strings.Cut(ref, ":")
+	p, ok := s.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("no secret provider for %q", scheme)
+	}
+	return p.Resolve(ctx, ref)
+}
+
+// resolveFile reads file:///run/secrets/pg_password, dropping the trailing
+// newline most editors and secret mounts leave behind.
+func resolveFile(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(strings.TrimPrefix(ref, "file://"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveEnv reads env:PG_PASSWORD. An unset variable is an error, an empty
+// one is not.
+func resolveEnv(_ context.Context, ref string) (string, error) {
+	name := strings.TrimPrefix(ref, "env:")
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveSecrets replaces every string field of cfg holding a reference
+// with its value. It returns the references by dotted key and the values
+// they resolved to.
+func resolveSecrets(ctx context.Context, s *Secrets, cfg any) (refs, values map[string]string, err error) {
+	refs, values = make(map[string]string), make(map[string]string)
+	var fields []FieldError
+	walkStrings(reflect.ValueOf(cfg), "", func(key string, v reflect.Value) {
+		ref := v.String()
+		if !s.IsRef(ref) {
+			return
+		}
+		value, err := s.Resolve(ctx, ref)
+		if err != nil {
+			fields = append(fields, FieldError{Field: key, Msg: fmt.Sprintf("resolve secret %s: %v", ref, err)})
+			return
+		}
+		v.SetString(value)
+		refs[key], values[key] = ref, value
+	})
+	if len(fields) > 0 {
+		return nil, nil, &ValidationError{Fields: fields}
+	}
+	return refs, values, nil
+}
+
+// walkStrings calls fn with every settable string field under v.
+func walkStrings(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := fieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			walkStrings(v.Field(i), join(prefix, name), fn)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			fn(prefix, v)
+		}
+	}
+}
+
+// WatchSecrets resolves the secret references of the loaded config again
+// every interval and calls fn with the key and new value of each secret
+// that changed, e.g. to hand a rotated password to a connection pool. The
+// config itself is not modified. Failed lookups are logged and retried on
+// the next tick. WatchSecrets blocks until ctx is done and returns at once
+// when the config holds no references or interval is not positive.
+func (s Source) WatchSecrets(ctx context.Context, interval time.Duration, fn func(key, value string)) {
+	if len(s.Secrets) == 0 || interval <= 0 {
+		return
+	}
+	last := maps.Clone(s.resolved)
+	keys := slices.Sorted(maps.Keys(s.Secrets))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, key := range keys {
+			ref := s.Secrets[key]
+			value, err := s.secrets.Resolve(ctx, ref)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					slog.Warn("Failed to refresh secret", "key", key, "ref", ref, "error", err)
+				}
+				continue
+			}
+			if value != last[key] {
+				last[key] = value
+				fn(key, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoad_ResolvesSecretRefs(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "pg_password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": baseYAML,
+		"dev.yaml":  "database:\n  password: file://" + secretFile + "\n  replica: env:TEST_REPLICA_DSN\n",
+	})
+	t.Setenv("TEST_REPLICA_DSN", "postgres://replica/users")
+	t.Setenv("TEST_SERVICE_DATABASE_KEY", "env:TEST_DB_KEY")
+	t.Setenv("TEST_DB_KEY", "from-env")
+
+	var cfg testConfig
+	src, err := Load(&cfg, Options{EnvPrefix: "TEST_SERVICE", Paths: []string{dir}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Database.Password != "from-file" || cfg.Database.Key != "from-env" || cfg.Database.Replica != "postgres://replica/users" {
+		t.Errorf("database = %+v", cfg.Database)
+	}
+	if cfg.Database.URI != "postgres://localhost/users" {
+		t.Errorf("uri = %q, want URIs left alone", cfg.Database.URI)
+	}
+	if src.Secrets["database.key"] != "env:TEST_DB_KEY" || len(src.Secrets) != 3 {
+		t.Errorf("secrets = %v", src.Secrets)
+	}
+
+	var buf bytes.Buffer
+	LogEffective(slog.New(slog.NewTextHandler(&buf, nil)), src, &cfg)
+	if strings.Contains(buf.String(), "replica/users") || strings.Contains(buf.String(), "from-") {
+		t.Errorf("resolved secrets logged: %s", buf.String())
+	}
+}
+
+func TestLoad_UnresolvedSecretRefs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"dev.yaml": baseYAML + "  password: env:TEST_MISSING\n  key: file:///nonexistent/key\n",
+	})
+
+	var cfg testConfig
+	_, err := Load(&cfg, Options{Paths: []string{dir}})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("err = %v, want both references reported", err)
+	}
+	if !strings.HasPrefix(verr.Fields[0].Error(), "database.password: resolve secret env:TEST_MISSING") {
+		t.Errorf("error = %q", verr.Fields[0].Error())
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/user-service":
+			w.Write([]byte(`{"data":{"data":{"db_password":"kv2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/user-service":
+			w.Write([]byte(`{"data":{"db_password":"kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewVaultProvider(srv.URL+"/", "s.token")
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "vault://secret/data/user-service#db_password", want: "kv2"},
+		{ref: "vault://kv/user-service#db_password", want: "kv1"},
+		{ref: "vault://secret/data/user-service#missing", wantErr: `has no field "missing"`},
+		{ref: "vault://secret/data/other#db_password", wantErr: "404 Not Found"},
+		{ref: "vault://secret/data/user-service", wantErr: "must look like"},
+	}
+	for _, tt := range tests {
+		got, err := p.Resolve(context.Background(), tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %q, %v; want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	if _, err := NewVaultProvider(srv.URL, "wrong").Resolve(context.Background(), tests[0].ref); err == nil {
+		t.Error("expected the bad token to be rejected")
+	}
+}
+
+func TestWatchSecrets(t *testing.T) {
+	var version atomic.Int32
+	secrets := NewSecrets()
+	secrets.Register("test", SecretProviderFunc(func(_ context.Context, ref string) (string, error) {
+		if version.Load() == 0 {
+			return "v0", nil
+		}
+		return "v1", nil
+	}))
+	dir := writeFiles(t, map[string]string{"dev.yaml": baseYAML + "  password: test:pg\n"})
+
+	var cfg testConfig
+	src, err := Load(&cfg, Options{Paths: []string{dir}, Secrets: secrets})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Database.Password != "v0" {
+		t.Fatalf("password = %q", cfg.Database.Password)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan string, 4)
+	done := make(chan struct{})
+	go func() {
+		src.WatchSecrets(ctx, 5*time.Millisecond, func(key, value string) { changes <- key + "=" + value })
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	version.Store(1)
+	select {
+	case got := <-changes:
+		if got != "database.password=v1" {
+			t.Errorf("change = %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no change reported")
+	}
+
+	cancel()
+	<-done
+	if len(changes) != 0 {
+		t.Errorf("unchanged secret reported again: %q", <-changes)
+	}
+	if cfg.Database.Password != "v0" {
+		t.Errorf("config modified: %q", cfg.Database.Password)
+	}
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError reports an invalid field by its dotted key, e.g. http.port.
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Msg
+	}
+	return e.Field + ": " + e.Msg
+}
+
+// ValidationError lists every invalid field of a configuration.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config (%d errors):", len(e.Fields))
+	for _, f := range e.Fields {
+		b.WriteString("\n  - ")
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Validator is implemented by config structs with rules the tags cannot
+// express. Returned FieldErrors are relative to the struct.
+type Validator interface {
+	Validate() error
+}
+
+// Validate checks cfg, a pointer to a struct, against its validate tags and
+// the Validate methods of cfg and its nested structs, and returns a
+// *ValidationError listing every problem. Structs in slices are checked
+// too, reported as e.g. database.replicas[0].host. Tag rules are comma separated:
+//
+//	required       the value must not be zero
+//	omitempty      skip the other rules when the value is zero
+//	min=N, max=N   bounds for numbers and durations, or lengths
+//	oneof=a b c    allowed string values
+//
+// A nil pointer is zero; a set one is checked by the value it points to.
+func Validate(cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errNotStruct
+	}
+
+	var fields []FieldError
+	validateStruct(v.Elem(), "", &fields)
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, out *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		key := join(prefix, name)
+		fv := v.Field(i)
+
+		if tag := f.Tag.Get("validate"); tag != "" {
+			if msg := checkRules(fv, tag); msg != "" {
+				*out = append(*out, FieldError{Field: key, Msg: msg})
+			}
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct && fv.Type() != durationType:
+			validateStruct(fv, key, out)
+		case fv.Kind() == reflect.Pointer && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+			validateStruct(fv.Elem(), key, out)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				validateStruct(fv.Index(j), fmt.Sprintf("%s[%d]", key, j), out)
+			}
+		}
+	}
+
+	if v.CanAddr() {
+		if val, ok := v.Addr().Interface().(Validator); ok {
+			collect(val.Validate(), prefix, out)
+		}
+	}
+}
+
+// collect flattens err, which may join several FieldErrors, into out.
+func collect(err error, prefix string, out *[]FieldError) {
+	if err == nil {
+		return
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			collect(e, prefix, out)
+		}
+		return
+	}
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		for _, f := range verr.Fields {
+			*out = append(*out, FieldError{Field: join(prefix, f.Field), Msg: f.Msg})
+		}
+		return
+	}
+	var ferr FieldError
+	if errors.As(err, &ferr) {
+		*out = append(*out, FieldError{Field: join(prefix, ferr.Field), Msg: ferr.Msg})
+		return
+	}
+	*out = append(*out, FieldError{Field: prefix, Msg: err.Error()})
+}
+
+func join(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+func checkRules(v reflect.Value, tag string) string {
+	rules := strings.Split(tag, ",")
+	if v.IsZero() {
+		if slices.Contains(rules, "required") {
+			return "is required"
+		}
+		if slices.Contains(rules, "omitempty") {
+			return ""
+		}
+	}
+
+	// a set pointer is checked by the value it points to
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required", "omitempty":
+		case "min", "max":
+			if msg := checkBound(v, name, arg); msg != "" {
+				return msg
+			}
+		case "oneof":
+			allowed := strings.Fields(arg)
+			if v.Kind() == reflect.String && !slices.Contains(allowed, v.String()) {
+				return fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), v.String())
+			}
+		default:
+			return fmt.Sprintf("unknown validate rule %q", rule)
+		}
+	}
+	return ""
+}
+
+func checkBound(v reflect.Value, rule, arg string) string {
+	atLeast := rule == "min"
+	word := "at least"
+	if !atLeast {
+		word = "at most"
+	}
+
+	switch {
+	case v.Type() == durationType:
+		limit, err := time.ParseDuration(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", rule, arg)
+		}
+		got := time.Duration(v.Int())
+		if (atLeast && got < limit) || (!atLeast && got > limit) {
+			return fmt.Sprintf("must be %s %s, got %s", word, limit, got)
+		}
+	case v.CanInt():
+		limit, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", rule, arg)
+		}
+		if got := v.Int(); (atLeast && got < limit) || (!atLeast && got > limit) {
+			return fmt.Sprintf("must be %s %d, got %d", word, limit, got)
+		}
+	case v.CanUint():
+		limit, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", rule, arg)
+		}
+		if got := v.Uint(); (atLeast && got < limit) || (!atLeast && got > limit) {
+			return fmt.Sprintf("must be %s %d, got %d", word, limit, got)
+		}
+	case v.CanFloat():
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", rule, arg)
+		}
+		if got := v.Float(); (atLeast && got < limit) || (!atLeast && got > limit) {
+			return fmt.Sprintf("must be %s %g, got %g", word, limit, got)
+		}
+	case v.Kind() == reflect.String || v.Kind() == reflect.Slice || v.Kind() == reflect.Map:
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", rule, arg)
+		}
+		if got := v.Len(); (atLeast && got < limit) || (!atLeast && got > limit) {
+			return fmt.Sprintf("length must be %s %d, got %d", word, limit, got)
+		}
+	}
+	return ""
+}
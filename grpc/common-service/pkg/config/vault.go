@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets over the Vault HTTP API. A reference names
+// the secret path and the field within it:
+//
+//	vault://secret/data/user-service#db_password
+//
+// Both KV version 2 responses, which nest the fields under data.data, and
+// version 1 responses are understood.
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider talks to the Vault server at addr, e.g.
+// https://vault:8200, with the given token.
+func NewVaultProvider(addr, token string) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(strings.TrimPrefix(ref, "vault://"), "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference %q must look like vault://<path>#<field>", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/"+(&url.URL{Path: path}).EscapedPath(), nil)
+	if err != nil {
+		return "", err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: GET %s: %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault: decode %s: %w", path, err)
+	}
+
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no field %q", path, field)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault: field %q of %s is not a string", field, path)
+	}
+	return s, nil
+}
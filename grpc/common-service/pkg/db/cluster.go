@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultCheckInterval = 5 * time.Second
+	defaultCheckTimeout  = time.Second
+
+	primaryNode = "primary"
+
+	// postgresLagQuery is the replay lag of a standby in seconds. A standby
+	// with a running WAL receiver that replayed everything it received has
+	// no lag, however long ago the primary last wrote. Without a receiver,
+	// e.g. after losing the primary, the standby may miss any number of
+	// writes, so its lag is the time since the last replayed transaction, or
+	// since it started when it replayed none. The receiver row is checked by
+	// its existence because unprivileged roles only see its pid.
+	postgresLagQuery = `SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+			AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver) THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - COALESCE(pg_last_xact_replay_timestamp(), pg_postmaster_start_time()))
+	END`
+)
+
+// ReplicaConfig controls which replicas a Cluster reads from.
+type ReplicaConfig struct {
+	// MaxLag is the replication lag above which a replica is not read from.
+	// Zero accepts any lag.
+	MaxLag time.Duration `mapstructure:"max_lag"`
+	// CheckInterval is how often replicas are probed, 5s by default.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	// CheckTimeout bounds each probe, 1s by default.
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`
+}
+
+// Replica is a named read-only pool.
+type Replica struct {
+	Name string
+	DB   *sql.DB
+}
+
+type replica struct {
+	Replica
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+// Cluster is a primary pool and the replica pools reads may go to. Writes
+// and transactions always use the primary; reads use a healthy replica
+// when the context or the call asks for one, and the primary otherwise.
+type Cluster struct {
+	primary  *sql.DB
+	replicas []*replica
+	cfg      ReplicaConfig
+	lagQuery string
+	next     atomic.Uint64
+}
+
+// NewCluster returns a cluster over primary and replicas. Replicas are
+// only read from once Check or Watch found them healthy.
+func NewCluster(primary *sql.DB, replicas []Replica, cfg ReplicaConfig) *Cluster {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultCheckInterval
+	}
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = defaultCheckTimeout
+	}
+
+	c := &Cluster{primary: primary, cfg: cfg, lagQuery: postgresLagQuery}
+	for _, r := range replicas {
+		c.replicas = append(c.replicas, &replica{Replica: r})
+	}
+	return c
+}
+
+// Primary returns the primary pool.
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Writer returns the transaction of ctx or the primary, see Conn.
+func (c *Cluster) Writer(ctx context.Context) DBTX {
+	return Conn(ctx, c.primary)
+}
+
+type routeKey struct{}
+
+type route int
+
+const (
+	routePrimary route = iota + 1
+	routeReplica
+)
+
+// ReadFromReplica lets reads made with ctx go to a replica. Use it where
+// slightly stale data is fine.
+func ReadFromReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routeReplica)
+}
+
+// ReadFromPrimary keeps reads made with ctx on the primary, even those
+// preferring a replica, e.g. to read a write just made.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routePrimary)
+}
+
+type readConfig struct {
+	preferReplica bool
+}
+
+// ReadOption configures a read routed by Reader.
+type ReadOption func(*readConfig)
+
+// PreferReplica sends the read to a replica unless ctx asks for the
+// primary. Use it for queries that tolerate replication lag.
+func PreferReplica() ReadOption {
+	return func(c *readConfig) {
+		c.preferReplica = true
+	}
+}
+
+// Reader returns where a read-only query should run: the transaction of
+// ctx if there is one, a healthy replica if ctx or opts ask for one, the
+// primary otherwise. The node is recorded on the span of ctx.
+func (c *Cluster) Reader(ctx context.Context, opts ...ReadOption) DBTX {
+	span := oteltrace.SpanFromContext(ctx)
+
+	if InTx(ctx, c.primary) {
+		span.SetAttributes(nodeAttributes(primaryNode, primaryNode)...)
+		return Conn(ctx, c.primary)
+	}
+
+	var cfg readConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	wantReplica := cfg.preferReplica
+	if r, ok := ctx.Value(routeKey{}).(route); ok {
+		wantReplica = r == routeReplica
+	}
+	if !wantReplica {
+		span.SetAttributes(nodeAttributes(primaryNode, primaryNode)...)
+		return c.primary
+	}
+
+	if r := c.pick(); r != nil {
+		span.SetAttributes(nodeAttributes(r.Name, "replica")...)
+		span.SetAttributes(attribute.Int64("db.replica.lag_ms", time.Duration(r.lag.Load()).Milliseconds()))
+		return r.DB
+	}
+
+	span.SetAttributes(nodeAttributes(primaryNode, primaryNode)...)
+	span.SetAttributes(attribute.Bool("db.replica.fallback", true))
+	return c.primary
+}
+
+func nodeAttributes(name, role string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("db.node.name", name),
+		attribute.String("db.node.role", role),
+	}
+}
+
+// pick returns the next healthy replica round robin, nil when none is.
+func (c *Cluster) pick() *replica {
+	n := len(c.replicas)
+	start := int(c.next.Add(1) % uint64(max(n, 1)))
+	for i := range n {
+		if r := c.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// Check probes every replica once. A replica is healthy when it answers
+// within CheckTimeout and lags behind the primary by at most MaxLag.
+func (c *Cluster) Check(ctx context.Context) {
+	for _, r := range c.replicas {
+		lag, err := c.probe(ctx, r)
+
+		healthy := err == nil
+		if healthy && c.cfg.MaxLag > 0 && lag > c.cfg.MaxLag {
+			err = fmt.Errorf("replication lag %s exceeds %s", lag, c.cfg.MaxLag)
+			healthy = false
+		}
+		r.lag.Store(int64(lag))
+
+		if was := r.healthy.Swap(healthy); was != healthy {
+			if healthy {
+				slog.Info("Replica is healthy", "replica", r.Name, "lag", lag)
+			} else {
+				slog.Warn("Replica is unhealthy, reading from other nodes", "replica", r.Name, "error", err)
+			}
+		}
+	}
+}
+
+func (c *Cluster) probe(ctx context.Context, r *replica) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.CheckTimeout)
+	defer cancel()
+
+	var seconds float64
+	if err := r.DB.QueryRowContext(ctx, c.lagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Watch checks the replicas now and every CheckInterval until ctx is done.
+func (c *Cluster) Watch(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the replica pools. The primary is left to its owner.
+func (c *Cluster) Close() error {
+	var errs []error
+	for _, r := range c.replicas {
+		if err := r.DB.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+)
+
+// lagDriver answers every query of the connections opened for a DSN with
+// the lag in seconds set for it, or fails when none is set.
+type lagDriver struct {
+	mu   sync.Mutex
+	lags map[string]float64
+}
+
+func (d *lagDriver) set(dsn string, seconds float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lags[dsn] = seconds
+}
+
+func (d *lagDriver) fail(dsn string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.lags, dsn)
+}
+
+func (d *lagDriver) Open(dsn string) (driver.Conn, error) { return &lagConn{d: d, dsn: dsn}, nil }
+
+type lagConn struct {
+	d   *lagDriver
+	dsn string
+}
+
+func (c *lagConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *lagConn) Close() error                        { return nil }
+func (c *lagConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *lagConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	seconds, ok := c.d.lags[c.dsn]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return &lagRows{seconds: seconds}, nil
+}
+
+type lagRows struct {
+	seconds float64
+	done    bool
+}
+
+func (r *lagRows) Columns() []string { return []string{"lag"} }
+func (r *lagRows) Close() error      { return nil }
+
+func (r *lagRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.seconds
+	return nil
+}
+
+var lagDB = &lagDriver{lags: map[string]float64{}}
+
+func init() {
+	sql.Register("lag", lagDB)
+}
+
+func openLag(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("lag", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newTestCluster(t *testing.T, cfg ReplicaConfig) (*Cluster, *sql.DB, *sql.DB, *sql.DB) {
+	t.Helper()
+
+	lagDB.set("r1", 0)
+	lagDB.set("r2", 0)
+	primary, r1, r2 := openLag(t, "primary"), openLag(t, "r1"), openLag(t, "r2")
+
+	return NewCluster(primary, []Replica{{Name: "r1", DB: r1}, {Name: "r2", DB: r2}}, cfg), primary, r1, r2
+}
+
+func TestCluster_Reader_Routing(t *testing.T) {
+	c, primary, r1, r2 := newTestCluster(t, ReplicaConfig{})
+	ctx := context.Background()
+
+	if got := c.Reader(ctx, PreferReplica()); got != primary {
+		t.Error("unchecked replicas are read from")
+	}
+
+	c.Check(ctx)
+
+	if got := c.Reader(ctx); got != primary {
+		t.Error("plain read does not go to the primary")
+	}
+	if got := c.Reader(ReadFromReplica(ctx)); got != r1 && got != r2 {
+		t.Error("ReadFromReplica read does not go to a replica")
+	}
+	if got := c.Reader(ReadFromPrimary(ctx), PreferReplica()); got != primary {
+		t.Error("ReadFromPrimary does not override PreferReplica")
+	}
+
+	seen := map[DBTX]bool{}
+	for range 4 {
+		seen[c.Reader(ctx, PreferReplica())] = true
+	}
+	if !seen[r1] || !seen[r2] || len(seen) != 2 {
+		t.Error("reads are not spread over both replicas")
+	}
+	if c.Writer(ctx) != primary {
+		t.Error("Writer does not return the primary")
+	}
+}
+
+func TestCluster_Reader_StaysInTransaction(t *testing.T) {
+	m, conn := newTestTxManager(t)
+	c := NewCluster(conn, []Replica{{Name: "r1", DB: openLag(t, "r1")}}, ReplicaConfig{})
+	lagDB.set("r1", 0)
+	c.Check(context.Background())
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, ok := c.Reader(ctx, PreferReplica()).(*sql.Tx); !ok {
+			t.Error("read inside a transaction leaves it")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCluster_Check_SkipsUnhealthyReplicas(t *testing.T) {
+	c, primary, _, r2 := newTestCluster(t, ReplicaConfig{MaxLag: time.Second})
+	ctx := context.Background()
+
+	lagDB.fail("r1")
+	c.Check(ctx)
+	for range 3 {
+		if got := c.Reader(ctx, PreferReplica()); got != r2 {
+			t.Fatal("read does not skip the failing replica")
+		}
+	}
+
+	lagDB.set("r2", 3)
+	c.Check(ctx)
+	if got := c.Reader(ctx, PreferReplica()); got != primary {
+		t.Error("read does not fall back to the primary when every replica is unhealthy or lagging")
+	}
+
+	lagDB.set("r2", 0.5)
+	c.Check(ctx)
+	if got := c.Reader(ctx, PreferReplica()); got != r2 {
+		t.Error("replica back within MaxLag is not read from")
+	}
+}
+
+func TestCluster_Reader_RecordsNode(t *testing.T) {
+	rec := tracetest.Install(t)
+	c, _, _, _ := newTestCluster(t, ReplicaConfig{})
+	lagDB.fail("r2")
+	c.Check(context.Background())
+
+	ctx, span := trace.StartSpan(context.Background(), "UserRepository.ListUsers")
+	c.Reader(ctx, PreferReplica())
+	span.End()
+
+	rec.Span(t, "UserRepository.ListUsers").
+		HasAttribute("db.node.name", "r1").
+		HasAttribute("db.node.role", "replica")
+
+	lagDB.fail("r1")
+	c.Check(context.Background())
+
+	ctx, span = trace.StartSpan(context.Background(), "UserRepository.CountUsers")
+	c.Reader(ctx, PreferReplica())
+	span.End()
+
+	rec.Span(t, "UserRepository.CountUsers").
+		HasAttribute("db.node.role", "primary").
+		HasAttribute("db.replica.fallback", true)
+}
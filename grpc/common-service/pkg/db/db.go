@@ -3,43 +3,212 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"common-service/pkg/ping"
 
 	_ "github.com/lib/pq"
-	"github.com/pressly/goose/v3"
 	"go.nhat.io/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-func InitDB(ctx context.Context, driver, dsn string) (*sql.DB, error) {
-	driverName, err := otelsql.Register("postgres",
-		otelsql.AllowRoot(),
-		otelsql.TraceQueryWithoutArgs(),
-		otelsql.TraceRowsClose(),
-		otelsql.TraceRowsAffected(),
-	)
-	if err != nil {
-		return nil, err
+const defaultDriver = "postgres"
+
+// Config tunes the pool InitDB opens. Zero values keep the database/sql
+// defaults.
+type Config struct {
+	// Driver is a registered database/sql driver name, postgres by default.
+	Driver string `mapstructure:"driver"`
+	// Name is the database name recorded on spans and metrics.
+	Name string `mapstructure:"name"`
+	// Node is recorded on spans and metrics as db.node.name, so that pools
+	// of the same database, e.g. read replicas, can be told apart.
+	Node string `mapstructure:"-"`
+
+	MaxOpenConns    int           `mapstructure:"max_open_conns" validate:"omitempty,min=1"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+
+	Ping ping.Config `mapstructure:"ping"`
+
+	// MeterProvider records the pool statistics, the global one when nil.
+	MeterProvider metric.MeterProvider `mapstructure:"-"`
+}
+
+func (c Config) driver() string {
+	if c.Driver == "" {
+		return defaultDriver
 	}
+	return c.Driver
+}
+
+// InitDB opens an instrumented pool for dsn, applies the pool limits of cfg,
+// records the pool statistics as OpenTelemetry metrics and pings the
+// database until it answers or the ping attempts run out.
+func InitDB(ctx context.Context, cfg Config, dsn string) (*sql.DB, error) {
+	return InitDBWithDSN(ctx, cfg, NewDSN(dsn))
+}
+
+// DSN holds the data source name a pool opens new connections with. Set
+// swaps it, e.g. after a password rotation, without reopening the pool:
+// connections already open keep working and later ones use the new DSN.
+type DSN struct {
+	v atomic.Value
+}
 
-	db, err := sql.Open(driverName, dsn)
+func NewDSN(dsn string) *DSN {
+	d := &DSN{}
+	d.v.Store(dsn)
+	return d
+}
+
+func (d *DSN) Get() string {
+	return d.v.Load().(string)
+}
+
+func (d *DSN) Set(dsn string) {
+	d.v.Store(dsn)
+}
+
+// InitDBWithDSN is InitDB for a DSN that may change while the pool is open.
+func InitDBWithDSN(ctx context.Context, cfg Config, dsn *DSN) (*sql.DB, error) {
+	db, attrs, err := open(cfg, dsn)
 	if err != nil {
 		return nil, err
 	}
 
 	// Verify DB is up
-	if err := db.PingContext(ctx); err != nil {
+	if err := cfg.Ping.Retry(ctx, db.PingContext); err != nil {
+		db.Close()
 		return nil, err
 	}
 
+	if err := recordStats(db, cfg, attrs); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenDBWithDSN is InitDBWithDSN without the ping, for a database that may
+// be down for now, e.g. a read replica the Cluster checks on its own.
+func OpenDBWithDSN(cfg Config, dsn *DSN) (*sql.DB, error) {
+	db, attrs, err := open(cfg, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordStats(db, cfg, attrs); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return db, nil
 }
 
-func ApplyMigrations(db *sql.DB, driver, migrationsDir string) error {
-	if err := goose.SetDialect(driver); err != nil {
-		return err
+// open opens the instrumented pool and returns the attributes recorded on
+// its spans.
+func open(cfg Config, dsn *DSN) (*sql.DB, []otelsql.Option, error) {
+	drv, err := lookupDriver(cfg.driver())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attrs := []otelsql.Option{otelsql.WithSystem(semconv.DBSystemKey.String(system(cfg.driver())))}
+	if cfg.Name != "" {
+		attrs = append(attrs, otelsql.WithDatabaseName(cfg.Name))
+	}
+	if cfg.Node != "" {
+		attrs = append(attrs, otelsql.WithDefaultAttributes(attribute.String("db.node.name", cfg.Node)))
+	}
+	driverOpts := []otelsql.DriverOption{
+		otelsql.AllowRoot(),
+		otelsql.TraceQueryWithoutArgs(),
+		otelsql.TraceRowsClose(),
+		otelsql.TraceRowsAffected(),
 	}
+	for _, o := range attrs {
+		driverOpts = append(driverOpts, o)
+	}
+
+	db := sql.OpenDB(&dsnConnector{driver: otelsql.Wrap(drv, driverOpts...), dsn: dsn})
+
+	// database/sql reads zero as a setting, e.g. no idle connections at all
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	return db, attrs, nil
+}
 
-	if err := goose.Up(db, migrationsDir); err != nil {
-		return err
+// recordStats records the pool statistics of db as OpenTelemetry metrics.
+func recordStats(db *sql.DB, cfg Config, attrs []otelsql.Option) error {
+	statsOpts := make([]otelsql.StatsOption, 0, len(attrs)+1)
+	for _, o := range attrs {
+		statsOpts = append(statsOpts, o)
+	}
+	if cfg.MeterProvider != nil {
+		statsOpts = append(statsOpts, otelsql.WithMeterProvider(cfg.MeterProvider))
+	}
+	if err := otelsql.RecordStats(db, statsOpts...); err != nil {
+		return fmt.Errorf("record %s pool stats: %w", cfg.driver(), err)
 	}
 	return nil
 }
+
+// lookupDriver returns the driver registered under name.
+func lookupDriver(name string) (driver.Driver, error) {
+	// sql.Open does not connect, it only looks the driver up
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.Driver(), nil
+}
+
+// system maps a driver name to the db.system semantic convention value.
+func system(driver string) string {
+	switch driver {
+	case "postgres", "pgx":
+		return "postgresql"
+	case "sqlite3", "sqlite":
+		return "sqlite"
+	}
+	return driver
+}
+
+// dsnConnector opens each connection with the DSN current at the time.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    *DSN
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(c.dsn.Get())
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(c.dsn.Get())
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
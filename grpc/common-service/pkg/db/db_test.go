@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"common-service/pkg/ping"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingDriver fails every Open and remembers the DSNs it was given.
+type recordingDriver struct {
+	dsns []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	d.dsns = append(d.dsns, name)
+	return nil, errors.New("no database")
+}
+
+func TestDSNConnector_UsesCurrentDSN(t *testing.T) {
+	drv := &recordingDriver{}
+	dsn := NewDSN("password=old")
+	db := sql.OpenDB(&dsnConnector{driver: drv, dsn: dsn})
+	defer db.Close()
+
+	_ = db.PingContext(context.Background())
+	dsn.Set("password=new")
+	_ = db.PingContext(context.Background())
+
+	if len(drv.dsns) < 2 || drv.dsns[0] != "password=old" || drv.dsns[len(drv.dsns)-1] != "password=new" {
+		t.Errorf("dsns = %v, want old then new", drv.dsns)
+	}
+}
+
+// flakyDriver fails the first failures opens, like a database that is
+// still starting.
+type flakyDriver struct {
+	failures atomic.Int32
+	opens    atomic.Int32
+}
+
+func (d *flakyDriver) Open(string) (driver.Conn, error) {
+	if d.opens.Add(1) <= d.failures.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var flaky = &flakyDriver{}
+
+func init() {
+	sql.Register("flaky", flaky)
+}
+
+func TestInitDB(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(2)
+	reader := sdkmetric.NewManualReader()
+
+	db, err := InitDB(context.Background(), Config{
+		Driver:          "flaky",
+		Name:            "users",
+		MaxOpenConns:    7,
+		MaxIdleConns:    3,
+		ConnMaxLifetime: time.Minute,
+		Ping:            ping.Config{Attempts: 3, BackoffInitial: time.Millisecond},
+		MeterProvider:   sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}, "dsn")
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := flaky.opens.Load(); got != 3 {
+		t.Errorf("opens = %d, want 2 failed pings and 1 success", got)
+	}
+	if got := db.Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("max open connections = %d, want 7", got)
+	}
+
+	// the connection of the successful ping stays open and idle
+	if got := gauge(t, reader, "db.sql.connections.open"); got != 1 {
+		t.Errorf("db.sql.connections.open = %d, want 1", got)
+	}
+	if got := gauge(t, reader, "db.sql.connections.idle"); got != 1 {
+		t.Errorf("db.sql.connections.idle = %d, want 1", got)
+	}
+}
+
+func TestInitDB_RecordsStatsWithGlobalMeterProvider(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(0)
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	db, err := InitDB(context.Background(), Config{Driver: "flaky"}, "dsn")
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := gauge(t, reader, "db.sql.connections.open"); got != 1 {
+		t.Errorf("db.sql.connections.open = %d, want 1", got)
+	}
+}
+
+// gauge collects reader and returns the value of the int64 gauge name.
+func gauge(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			g, ok := m.Data.(metricdata.Gauge[int64])
+			if !ok || len(g.DataPoints) != 1 {
+				t.Fatalf("%s = %+v, want one int64 gauge point", name, m.Data)
+			}
+			return g.DataPoints[0].Value
+		}
+	}
+	t.Fatalf("metric %s was not recorded", name)
+	return 0
+}
+
+func TestInitDB_ZeroConfigKeepsIdleConnections(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(0)
+
+	db, err := InitDB(context.Background(), Config{Driver: "flaky"}, "dsn")
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+
+	for range 3 {
+		if err := db.Ping(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := flaky.opens.Load(); got != 1 {
+		t.Errorf("opens = %d, want the first connection reused", got)
+	}
+}
+
+func TestInitDB_PingGivesUp(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(10)
+
+	_, err := InitDB(context.Background(), Config{
+		Driver: "flaky",
+		Ping:   ping.Config{Attempts: 2, BackoffInitial: time.Millisecond},
+	}, "dsn")
+	if err == nil || !strings.Contains(err.Error(), "ping database (2 attempts): connection refused") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := flaky.opens.Load(); got != 2 {
+		t.Errorf("opens = %d, want 2", got)
+	}
+}
+
+func TestOpenDBWithDSN_DatabaseDown(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(1)
+
+	db, err := OpenDBWithDSN(Config{Driver: "flaky"}, NewDSN("dsn"))
+	if err != nil {
+		t.Fatalf("OpenDBWithDSN: %v", err)
+	}
+	defer db.Close()
+
+	if got := flaky.opens.Load(); got != 0 {
+		t.Errorf("opens = %d, want no connection before first use", got)
+	}
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected the first ping to fail")
+	}
+	if err := db.Ping(); err != nil {
+		t.Errorf("pool did not recover once the database came up: %v", err)
+	}
+}
+
+func TestInitDB_UnknownDriver(t *testing.T) {
+	if _, err := InitDB(context.Background(), Config{Driver: "nope"}, "dsn"); err == nil {
+		t.Fatal("expected an error for an unregistered driver")
+	}
+}
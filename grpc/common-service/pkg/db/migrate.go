@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// MigrationMode says what a service does about its migrations at startup.
+type MigrationMode string
+
+const (
+	// MigrationModeMigrate applies pending migrations.
+	MigrationModeMigrate MigrationMode = "migrate"
+	// MigrationModeVerify fails when migrations are pending, for deployments
+	// that migrate in a separate step.
+	MigrationModeVerify MigrationMode = "verify"
+	// MigrationModeSkip leaves the schema alone.
+	MigrationModeSkip MigrationMode = "skip"
+)
+
+// ErrPendingMigrations is returned by Verify when the database schema is
+// behind the migrations.
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// ParseMigrationMode parses migrate, verify or skip. An empty s is migrate.
+func ParseMigrationMode(s string) (MigrationMode, error) {
+	switch mode := MigrationMode(s); mode {
+	case "":
+		return MigrationModeMigrate, nil
+	case MigrationModeMigrate, MigrationModeVerify, MigrationModeSkip:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown migration mode %q, want migrate, verify or skip", s)
+}
+
+// Migrator applies the SQL migrations of fsys. On Postgres every command
+// holds an advisory lock, so replicas starting together migrate one at a
+// time and the later ones find nothing left to do.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// NewMigrator reads the goose migrations at the root of fsys, typically an
+// embed.FS compiled into the service.
+func NewMigrator(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	var opts []goose.ProviderOption
+	if driver == "postgres" {
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, goose.WithSessionLocker(locker))
+	}
+
+	provider, err := goose.NewProvider(goose.Dialect(driver), db, fsys, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Run does what mode says with the pending migrations.
+func (m *Migrator) Run(ctx context.Context, mode MigrationMode) error {
+	switch mode {
+	case MigrationModeMigrate, "":
+		results, err := m.Up(ctx)
+		for _, r := range results {
+			slog.Info("Applied migration", "migration", r.Source.Path, "duration", r.Duration)
+		}
+		return err
+	case MigrationModeVerify:
+		return m.Verify(ctx)
+	case MigrationModeSkip:
+		return nil
+	}
+	return fmt.Errorf("unknown migration mode %q", mode)
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// Down rolls back the latest migration.
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+// Redo rolls back the latest migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) ([]*goose.MigrationResult, error) {
+	down, err := m.provider.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+// Status lists every migration with whether and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// Verify returns ErrPendingMigrations when migrations are not applied yet.
+func (m *Migrator) Verify(ctx context.Context) error {
+	current, target, err := m.provider.GetVersions(ctx)
+	if err != nil {
+		return err
+	}
+	pending, err := m.provider.HasPending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrPendingMigrations, current, target)
+	}
+	return nil
+}
+
+// CreateMigration writes an empty SQL migration called name into dir, the
+// source directory of the embedded migrations.
+func CreateMigration(dir, name string) error {
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// ApplyMigrations applies the pending migrations of fsys.
+func ApplyMigrations(ctx context.Context, db *sql.DB, driver string, fsys fs.FS) error {
+	m, err := NewMigrator(db, driver, fsys)
+	if err != nil {
+		return err
+	}
+	return m.Run(ctx, MigrationModeMigrate)
+}
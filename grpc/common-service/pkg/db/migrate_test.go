@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+)
+
+func TestParseMigrationMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    MigrationMode
+		wantErr bool
+	}{
+		{"", MigrationModeMigrate, false},
+		{"migrate", MigrationModeMigrate, false},
+		{"verify", MigrationModeVerify, false},
+		{"skip", MigrationModeSkip, false},
+		{"up", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMigrationMode(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMigrationMode(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+var testMigrations = fstest.MapFS{
+	"00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n\n-- +goose Down\nDROP TABLE users;\n")},
+}
+
+func TestNewMigrator(t *testing.T) {
+	conn, err := sql.Open("flaky", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := NewMigrator(conn, "postgres", testMigrations); err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	_, err = NewMigrator(conn, "postgres", fstest.MapFS{})
+	if !errors.Is(err, goose.ErrNoMigrations) {
+		t.Errorf("err = %v, want no migrations", err)
+	}
+}
+
+func TestMigrator_Run(t *testing.T) {
+	flaky.opens.Store(0)
+	flaky.failures.Store(100)
+	conn, err := sql.Open("flaky", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m, err := NewMigrator(conn, "postgres", testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Run(context.Background(), MigrationModeSkip); err != nil {
+		t.Errorf("skip: %v", err)
+	}
+	if got := flaky.opens.Load(); got != 0 {
+		t.Errorf("skip opened %d connections", got)
+	}
+
+	if err := m.Run(context.Background(), MigrationModeVerify); err == nil {
+		t.Error("verify succeeded without a database")
+	}
+	if err := m.Run(context.Background(), "sideways"); err == nil {
+		t.Error("unknown mode succeeded")
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := CreateMigration(dir, "add_phone"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*_add_phone.sql"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want one migration", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "-- +goose Up") {
+		t.Errorf("migration = %q, want a goose template", data)
+	}
+}
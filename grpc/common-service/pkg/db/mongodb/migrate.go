@@ -0,0 +1,348 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MigrationsCollection records the applied migration versions.
+	MigrationsCollection = "schema_migrations"
+	// LockCollection holds the document a running Migrate owns.
+	LockCollection = "schema_migrations_lock"
+
+	lockID           = "migrate"
+	defaultLockTTL   = time.Minute
+	defaultLockRetry = 500 * time.Millisecond
+)
+
+// Migration is a versioned schema change. Migrate applies every version
+// once, in order, and records it in MigrationsCollection.
+type Migration struct {
+	Version     int
+	Description string
+	// Collections are created, or brought to the declared validator, and
+	// get the declared indexes.
+	Collections []Collection
+	// Up runs after Collections for changes declarations cannot express,
+	// e.g. backfilling a field. It must be safe to run again should the
+	// migration fail before being recorded.
+	Up func(ctx context.Context, db *mongo.Database) error
+}
+
+// Collection declares a collection with its validator and indexes.
+type Collection struct {
+	Name string
+	// Schema is the $jsonSchema documents must match, nil for none.
+	Schema bson.M
+	// ValidationLevel is strict, the default, or moderate; ValidationAction
+	// is error, the default, or warn.
+	ValidationLevel  string
+	ValidationAction string
+	Indexes          []Index
+}
+
+// Index declares an index. Creating an index that exists with the same
+// keys and options does nothing, so declarations can be applied again.
+type Index struct {
+	// Name is required so the index is recognised on later runs.
+	Name string
+	// Keys are the indexed fields in order, 1 or -1 for the direction, or
+	// "text" for a text index.
+	Keys   bson.D
+	Unique bool
+	// TTL removes documents this long after the date in the single key.
+	TTL time.Duration
+	// Weights and DefaultLanguage tune a text index.
+	Weights         bson.D
+	DefaultLanguage string
+}
+
+func (i Index) validate() error {
+	switch {
+	case i.Name == "":
+		return errors.New("index without a name")
+	case len(i.Keys) == 0:
+		return fmt.Errorf("index %s has no keys", i.Name)
+	case i.TTL < 0 || i.TTL%time.Second != 0:
+		return fmt.Errorf("index %s: TTL must be whole seconds, got %s", i.Name, i.TTL)
+	case i.TTL > 0 && len(i.Keys) != 1:
+		return fmt.Errorf("index %s: TTL needs a single key", i.Name)
+	}
+	return nil
+}
+
+func (i Index) model() mongo.IndexModel {
+	opts := options.Index().SetName(i.Name)
+	if i.Unique {
+		opts.SetUnique(true)
+	}
+	if i.TTL > 0 {
+		opts.SetExpireAfterSeconds(int32(i.TTL / time.Second))
+	}
+	if len(i.Weights) > 0 {
+		opts.SetWeights(i.Weights)
+	}
+	if i.DefaultLanguage != "" {
+		opts.SetDefaultLanguage(i.DefaultLanguage)
+	}
+	return mongo.IndexModel{Keys: i.Keys, Options: opts}
+}
+
+// Migrator applies migrations to the database of a client. Several
+// instances may run at once: a lock document in LockCollection lets one
+// migrate while the others wait, and then find nothing left to do.
+type Migrator struct {
+	client     *MongoClient
+	migrations []Migration
+	owner      string
+
+	lockTTL   time.Duration
+	lockRetry time.Duration
+}
+
+// NewMigrator checks migrations, which need distinct positive versions and
+// named indexes, and returns a migrator applying them in version order.
+func NewMigrator(client *MongoClient, migrations ...Migration) (*Migrator, error) {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b Migration) int { return a.Version - b.Version })
+
+	for i, m := range sorted {
+		if m.Version < 1 {
+			return nil, fmt.Errorf("migration %q: version must be positive", m.Description)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration version %d declared twice", m.Version)
+		}
+		for _, c := range m.Collections {
+			if c.Name == "" {
+				return nil, fmt.Errorf("migration %d: collection without a name", m.Version)
+			}
+			for _, idx := range c.Indexes {
+				if err := idx.validate(); err != nil {
+					return nil, fmt.Errorf("migration %d, collection %s: %w", m.Version, c.Name, err)
+				}
+			}
+		}
+	}
+
+	host, _ := os.Hostname()
+	return &Migrator{
+		client:     client,
+		migrations: sorted,
+		owner:      fmt.Sprintf("%s/%d/%d", host, os.Getpid(), time.Now().UnixNano()),
+		lockTTL:    defaultLockTTL,
+		lockRetry:  defaultLockRetry,
+	}, nil
+}
+
+// Migrate applies the migrations not recorded yet, holding the lock
+// document meanwhile. It waits for a lock held by another instance until
+// ctx is done.
+func (m *Migrator) Migrate(ctx context.Context) (err error) {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	// keep the lock while migrations take longer than its TTL
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	defer stopRenew()
+	go m.renew(renewCtx)
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := m.client.DB
+	for _, mig := range m.migrations {
+		if applied[mig.Version] {
+			continue
+		}
+
+		start := time.Now()
+		for _, c := range mig.Collections {
+			if err := m.applyCollection(ctx, c); err != nil {
+				return fmt.Errorf("migration %d: %w", mig.Version, err)
+			}
+		}
+		if mig.Up != nil {
+			if err := mig.Up(ctx, db); err != nil {
+				return fmt.Errorf("migration %d: %w", mig.Version, err)
+			}
+		}
+
+		_, err := db.Collection(MigrationsCollection).InsertOne(ctx, bson.M{
+			"_id":         mig.Version,
+			"description": mig.Description,
+			"applied_at":  time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("record migration %d: %w", mig.Version, err)
+		}
+		slog.InfoContext(ctx, "Applied MongoDB migration", "version", mig.Version, "description", mig.Description, "duration", time.Since(start))
+	}
+	return nil
+}
+
+// applied returns the recorded migration versions.
+func (m *Migrator) applied(ctx context.Context) (map[int]bool, error) {
+	cur, err := m.client.DB.Collection(MigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	var docs []struct {
+		Version int `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	applied := make(map[int]bool, len(docs))
+	for _, d := range docs {
+		applied[d.Version] = true
+	}
+	return applied, nil
+}
+
+// applyCollection creates c or updates its validator, then creates its
+// indexes.
+func (m *Migrator) applyCollection(ctx context.Context, c Collection) error {
+	db := m.client.DB
+
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": c.Name})
+	if err != nil {
+		return fmt.Errorf("list collection %s: %w", c.Name, err)
+	}
+
+	switch {
+	case len(names) == 0:
+		opts := options.CreateCollection()
+		if c.Schema != nil {
+			opts.SetValidator(bson.M{"$jsonSchema": c.Schema})
+		}
+		if c.ValidationLevel != "" {
+			opts.SetValidationLevel(c.ValidationLevel)
+		}
+		if c.ValidationAction != "" {
+			opts.SetValidationAction(c.ValidationAction)
+		}
+		if err := m.client.CreateCollection(ctx, c.Name, opts); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+	case c.Schema != nil:
+		cmd := bson.D{
+			{Key: "collMod", Value: c.Name},
+			{Key: "validator", Value: bson.M{"$jsonSchema": c.Schema}},
+		}
+		if c.ValidationLevel != "" {
+			cmd = append(cmd, bson.E{Key: "validationLevel", Value: c.ValidationLevel})
+		}
+		if c.ValidationAction != "" {
+			cmd = append(cmd, bson.E{Key: "validationAction", Value: c.ValidationAction})
+		}
+		if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+			return fmt.Errorf("update validator of %s: %w", c.Name, err)
+		}
+	}
+
+	if len(c.Indexes) == 0 {
+		return nil
+	}
+	models := make([]mongo.IndexModel, len(c.Indexes))
+	for i, idx := range c.Indexes {
+		models[i] = idx.model()
+	}
+	if _, err := db.Collection(c.Name).Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("indexes of %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// lock takes the lock document, waiting while another owner holds it. A
+// lock whose owner stopped renewing it expires after lockTTL.
+func (m *Migrator) lock(ctx context.Context) error {
+	for {
+		ok, err := m.tryLock(ctx)
+		if err != nil {
+			return fmt.Errorf("take migration lock: %w", err)
+		}
+		if ok {
+			return nil
+		}
+
+		slog.InfoContext(ctx, "Waiting for another instance to finish MongoDB migrations")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("take migration lock: %w", ctx.Err())
+		case <-time.After(m.lockRetry):
+		}
+	}
+}
+
+// tryLock takes the lock if it is free or expired. The upsert inserts a
+// second document with the same _id when the lock is held, which fails
+// with a duplicate key error. Expiry is set and compared with the server
+// clock, so instances with skewed clocks agree on it.
+func (m *Migrator) tryLock(ctx context.Context) (bool, error) {
+	_, err := m.client.DB.Collection(LockCollection).UpdateOne(ctx,
+		bson.M{"_id": lockID, "$expr": bson.M{"$lt": bson.A{"$expires_at", "$$NOW"}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"owner": m.owner, "expires_at": m.lockExpiry()}}}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// renew pushes the expiry of the lock forward until ctx is done.
+func (m *Migrator) renew(ctx context.Context) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := m.client.DB.Collection(LockCollection).UpdateOne(ctx,
+			bson.M{"_id": lockID, "owner": m.owner},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"expires_at": m.lockExpiry()}}}},
+		)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to renew MongoDB migration lock", "error", err)
+		}
+	}
+}
+
+// lockExpiry is an aggregation expression for lockTTL from now on the
+// server clock.
+func (m *Migrator) lockExpiry() bson.M {
+	return bson.M{"$add": bson.A{"$$NOW", m.lockTTL.Milliseconds()}}
+}
+
+func (m *Migrator) unlock(ctx context.Context) error {
+	_, err := m.client.DB.Collection(LockCollection).DeleteOne(ctx, bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		return fmt.Errorf("release migration lock: %w", err)
+	}
+	return nil
+}
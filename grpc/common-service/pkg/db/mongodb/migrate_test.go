@@ -0,0 +1,244 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIndex_Model(t *testing.T) {
+	unique := Index{Name: "users_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}.model()
+	if *unique.Options.Name != "users_email" || !*unique.Options.Unique || unique.Options.ExpireAfterSeconds != nil {
+		t.Errorf("unique index options = %+v", unique.Options)
+	}
+
+	ttl := Index{Name: "sessions_ttl", Keys: bson.D{{Key: "created_at", Value: 1}}, TTL: time.Hour}.model()
+	if *ttl.Options.ExpireAfterSeconds != 3600 {
+		t.Errorf("expireAfterSeconds = %d, want 3600", *ttl.Options.ExpireAfterSeconds)
+	}
+
+	text := Index{
+		Name:            "products_text",
+		Keys:            bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Weights:         bson.D{{Key: "name", Value: 10}},
+		DefaultLanguage: "english",
+	}.model()
+	if text.Options.Weights == nil || *text.Options.DefaultLanguage != "english" {
+		t.Errorf("text index options = %+v", text.Options)
+	}
+}
+
+func TestNewMigrator_RejectsInvalidDeclarations(t *testing.T) {
+	keys := bson.D{{Key: "a", Value: 1}}
+	tests := []struct {
+		name string
+		migs []Migration
+		want string
+	}{
+		{"zero version", []Migration{{Description: "x"}}, "version must be positive"},
+		{"duplicate version", []Migration{{Version: 1}, {Version: 1}}, "version 1 declared twice"},
+		{"unnamed collection", []Migration{{Version: 1, Collections: []Collection{{}}}}, "collection without a name"},
+		{"unnamed index", []Migration{{Version: 1, Collections: []Collection{{Name: "c", Indexes: []Index{{Keys: keys}}}}}}, "index without a name"},
+		{"compound TTL", []Migration{{Version: 1, Collections: []Collection{{Name: "c", Indexes: []Index{{
+			Name: "i", Keys: bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 1}}, TTL: time.Hour,
+		}}}}}}, "TTL needs a single key"},
+		{"fractional TTL", []Migration{{Version: 1, Collections: []Collection{{Name: "c", Indexes: []Index{{
+			Name: "i", Keys: keys, TTL: 1500 * time.Millisecond,
+		}}}}}}, "TTL must be whole seconds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMigrator(&MongoClient{}, tt.migs...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMigrator_SortsByVersion(t *testing.T) {
+	m, err := NewMigrator(&MongoClient{}, Migration{Version: 3}, Migration{Version: 1}, Migration{Version: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mig := range m.migrations {
+		if mig.Version != i+1 {
+			t.Fatalf("versions out of order: %+v", m.migrations)
+		}
+	}
+}
+
+// testClient connects to E2E_MONGODB_URI and returns a client on a fresh
+// database, skipping the test when the variable is not set.
+func testClient(t *testing.T) *MongoClient {
+	t.Helper()
+
+	uri := os.Getenv("E2E_MONGODB_URI")
+	if uri == "" {
+		t.Skip("E2E_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := NewMongoDBClient(ctx, Config{URI: uri, Database: fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.DB.Drop(ctx)
+		_ = client.Close(ctx)
+	})
+	return client
+}
+
+var testMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "users",
+		Collections: []Collection{{
+			Name: "users",
+			Schema: bson.M{
+				"bsonType": "object",
+				"required": bson.A{"email"},
+				"properties": bson.M{
+					"email": bson.M{"bsonType": "string"},
+				},
+			},
+			Indexes: []Index{
+				{Name: "users_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+				{Name: "users_created_at_ttl", Keys: bson.D{{Key: "created_at", Value: 1}}, TTL: 24 * time.Hour},
+			},
+		}},
+	},
+	{
+		Version:     2,
+		Description: "user search",
+		Collections: []Collection{{
+			Name: "users",
+			Indexes: []Index{
+				{Name: "users_name_email", Keys: bson.D{{Key: "name", Value: 1}, {Key: "email", Value: 1}}},
+				{Name: "users_text", Keys: bson.D{{Key: "name", Value: "text"}}},
+			},
+		}},
+	},
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	// instances starting together migrate once
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := NewMigrator(client, testMigrations...)
+			if err == nil {
+				err = m.Migrate(ctx)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+	}
+
+	if n, _ := client.DB.Collection(MigrationsCollection).CountDocuments(ctx, bson.M{}); n != 2 {
+		t.Errorf("recorded migrations = %d, want 2", n)
+	}
+	if n, _ := client.DB.Collection(LockCollection).CountDocuments(ctx, bson.M{}); n != 0 {
+		t.Errorf("lock documents = %d, want the lock released", n)
+	}
+
+	users := client.DB.Collection("users")
+	if _, err := users.InsertOne(ctx, bson.M{"name": "no email"}); err == nil {
+		t.Error("validator accepted a user without email")
+	}
+	if _, err := users.InsertOne(ctx, bson.M{"email": "a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.InsertOne(ctx, bson.M{"email": "a@example.com"}); !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("err = %v, want a duplicate key error", err)
+	}
+
+	specs, err := users.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, s := range specs {
+		names[s.Name] = true
+	}
+	for _, want := range []string{"users_email", "users_created_at_ttl", "users_name_email", "users_text"} {
+		if !names[want] {
+			t.Errorf("index %s missing, have %v", want, names)
+		}
+	}
+}
+
+func TestMigrator_WaitsForLock(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	_, err := client.DB.Collection(LockCollection).InsertOne(ctx, bson.M{
+		"_id":        lockID,
+		"owner":      "someone else",
+		"expires_at": time.Now().UTC().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(client, testMigrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lockRetry = 10 * time.Millisecond
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err := m.Migrate(waitCtx); err == nil {
+		t.Fatal("migrated while another instance held the lock")
+	}
+	if n, _ := client.DB.Collection(MigrationsCollection).CountDocuments(ctx, bson.M{}); n != 0 {
+		t.Errorf("recorded migrations = %d, want none", n)
+	}
+}
+
+func TestMigrator_TakesOverExpiredLock(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	_, err := client.DB.Collection(LockCollection).InsertOne(ctx, bson.M{
+		"_id":        lockID,
+		"owner":      "crashed instance",
+		"expires_at": time.Now().UTC().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(client, testMigrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if n, _ := client.DB.Collection(MigrationsCollection).CountDocuments(ctx, bson.M{}); n != 2 {
+		t.Errorf("recorded migrations = %d, want 2", n)
+	}
+}
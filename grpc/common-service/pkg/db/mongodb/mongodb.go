@@ -3,19 +3,124 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	pkgconfig "common-service/pkg/config"
+	"common-service/pkg/ping"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
+// Config configures the client NewMongoDBClient connects. Zero values keep
+// the driver defaults and whatever the URI sets.
 type Config struct {
-	URI            string        `json:"uri" yaml:"uri"`
-	Database       string        `json:"database" yaml:"database"`
-	MaxPoolSize    int           `json:"max_pool_size" yaml:"max_pool_size"`
-	ConnectTimeout time.Duration `json:"connect_timeout" yaml:"connect_timeout"`
+	URI      string `mapstructure:"uri" validate:"required"`
+	Database string `mapstructure:"database" validate:"required"`
+
+	// Username, Password and AuthSource replace the credentials of URI when
+	// Username is set, so the password can be a secret reference.
+	Username   string `mapstructure:"username"`
+	Password   string `mapstructure:"password"`
+	AuthSource string `mapstructure:"auth_source"`
+
+	MaxPoolSize            int           `mapstructure:"max_pool_size" validate:"omitempty,min=1"`
+	MinPoolSize            int           `mapstructure:"min_pool_size" validate:"omitempty,min=0"`
+	ConnectTimeout         time.Duration `mapstructure:"connect_timeout"`
+	ServerSelectionTimeout time.Duration `mapstructure:"server_selection_timeout"`
+	// Timeout bounds every operation unless its context ends earlier.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	ReadPreference string       `mapstructure:"read_preference" validate:"omitempty,oneof=primary primaryPreferred secondary secondaryPreferred nearest"`
+	ReadConcern    string       `mapstructure:"read_concern" validate:"omitempty,oneof=local available majority linearizable snapshot"`
+	WriteConcern   WriteConcern `mapstructure:"write_concern"`
+
+	// Ping controls how long NewMongoDBClient waits for the server.
+	Ping ping.Config `mapstructure:"ping"`
+}
+
+type WriteConcern struct {
+	// W is the number of members that acknowledge a write, or majority.
+	W       string `mapstructure:"w"`
+	Journal bool   `mapstructure:"journal"`
+}
+
+func (c *WriteConcern) Validate() error {
+	if c.W == "" || c.W == "majority" {
+		return nil
+	}
+	if n, err := strconv.Atoi(c.W); err != nil || n < 0 {
+		return pkgconfig.FieldError{Field: "w", Msg: fmt.Sprintf("must be majority or a number of members, got %q", c.W)}
+	}
+	return nil
+}
+
+// ClientOptions returns the driver options for cfg, instrumented with
+// OpenTelemetry.
+func (c Config) ClientOptions() (*options.ClientOptions, error) {
+	opts := options.Client().
+		ApplyURI(c.URI).
+		SetMonitor(otelmongo.NewMonitor())
+
+	if c.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:   c.Username,
+			Password:   c.Password,
+			AuthSource: c.AuthSource,
+		})
+	}
+	if c.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(uint64(c.MaxPoolSize))
+	}
+	if c.MinPoolSize > 0 {
+		opts.SetMinPoolSize(uint64(c.MinPoolSize))
+	}
+	if c.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(c.ConnectTimeout)
+	}
+	if c.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(c.ServerSelectionTimeout)
+	}
+	if c.Timeout > 0 {
+		opts.SetTimeout(c.Timeout)
+	}
+
+	if c.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(c.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadPreference(rp)
+	}
+	if c.ReadConcern != "" {
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: c.ReadConcern})
+	}
+	if c.WriteConcern.W != "" || c.WriteConcern.Journal {
+		if err := c.WriteConcern.Validate(); err != nil {
+			return nil, err
+		}
+		wc := &writeconcern.WriteConcern{}
+		if n, err := strconv.Atoi(c.WriteConcern.W); err == nil {
+			wc.W = n
+		} else if c.WriteConcern.W != "" {
+			wc.W = c.WriteConcern.W
+		}
+		if c.WriteConcern.Journal {
+			wc.Journal = &c.WriteConcern.Journal
+		}
+		opts.SetWriteConcern(wc)
+	}
+
+	return opts, opts.Validate()
 }
 
 type MongoClient struct {
@@ -23,22 +128,26 @@ type MongoClient struct {
 	DB     *mongo.Database
 }
 
-func NewMongoDBClient(cfg Config) (*MongoClient, error) {
-	// Set up client options
-	opts := options.Client().
-		ApplyURI(cfg.URI).
-		SetMaxPoolSize(uint64(cfg.MaxPoolSize)).
-		SetConnectTimeout(cfg.ConnectTimeout).
-		SetMonitor(otelmongo.NewMonitor())
+// NewMongoDBClient connects to cfg.URI and pings the server until it
+// answers or the ping attempts run out. Cancelling ctx stops waiting.
+func NewMongoDBClient(ctx context.Context, cfg Config) (*MongoClient, error) {
+	opts, err := cfg.ClientOptions()
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB options: %w", err)
+	}
 
 	// Create client
-	client, err := mongo.Connect(context.TODO(), opts)
+	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
 
 	// Verify connection
-	if err := client.Ping(context.TODO(), readpref.Primary()); err != nil {
+	err = cfg.Ping.Retry(ctx, func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
+	if err != nil {
+		_ = client.Disconnect(context.WithoutCancel(ctx))
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
@@ -48,24 +157,26 @@ func NewMongoDBClient(cfg Config) (*MongoClient, error) {
 	}, nil
 }
 
-// Close terminates the MongoDB connection
-func (c *MongoClient) Close() error {
+// Close terminates the MongoDB connection, waiting for in-use connections
+// until ctx is done.
+func (c *MongoClient) Close(ctx context.Context) error {
 	if c.Client == nil {
 		return nil
 	}
-	return c.Client.Disconnect(context.TODO())
+	return c.Client.Disconnect(ctx)
 }
 
 // Ping checks the health of the MongoDB connection
-func (c *MongoClient) Ping() error {
-	return c.Client.Ping(context.TODO(), readpref.Primary())
+func (c *MongoClient) Ping(ctx context.Context) error {
+	return c.Client.Ping(ctx, readpref.Primary())
 }
 
-func (c *MongoClient) CreateCollection(name string) error {
+// CreateCollection creates collection name, e.g. with a validator.
+func (c *MongoClient) CreateCollection(ctx context.Context, name string, opts ...*options.CreateCollectionOptions) error {
 	if c.DB == nil {
 		return fmt.Errorf("database is not initialized")
 	}
-	if err := c.DB.CreateCollection(context.TODO(), name); err != nil {
+	if err := c.DB.CreateCollection(ctx, name, opts...); err != nil {
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
 	return nil
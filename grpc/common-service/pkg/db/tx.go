@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"common-service/pkg/trace"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTxRetries        = 3
+	defaultTxBackoffInitial = 20 * time.Millisecond
+	defaultTxBackoffMax     = 500 * time.Millisecond
+)
+
+// DBTX is the query surface shared by *sql.DB and *sql.Tx. Repositories run
+// their statements on Conn(ctx, db) so they join the transaction of the
+// caller when there is one.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type txKey struct{}
+
+// txState is the transaction WithinTx keeps in the context.
+type txState struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	depth int
+}
+
+// Conn returns the transaction of ctx when it was started on db, db itself
+// otherwise.
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok && st.db == db {
+		return st.tx
+	}
+	return db
+}
+
+// InTx reports whether ctx carries a transaction started on db.
+func InTx(ctx context.Context, db *sql.DB) bool {
+	st, ok := ctx.Value(txKey{}).(*txState)
+	return ok && st.db == db
+}
+
+type txConfig struct {
+	opts       sql.TxOptions
+	maxRetries int
+}
+
+// TxOption configures a transaction started by WithinTx.
+type TxOption func(*txConfig)
+
+// WithIsolation sets the isolation level of the transaction.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(c *txConfig) {
+		c.opts.Isolation = level
+	}
+}
+
+// ReadOnly starts a read-only transaction.
+func ReadOnly() TxOption {
+	return func(c *txConfig) {
+		c.opts.ReadOnly = true
+	}
+}
+
+// WithMaxRetries sets how often a transaction failing with a serialization
+// failure is run again, 3 by default. Zero disables retries.
+func WithMaxRetries(n int) TxOption {
+	return func(c *txConfig) {
+		c.maxRetries = max(n, 0)
+	}
+}
+
+// TxManager runs units of work in a transaction on one pool.
+type TxManager struct {
+	db *sql.DB
+
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{
+		db:             db,
+		backoffInitial: defaultTxBackoffInitial,
+		backoffMax:     defaultTxBackoffMax,
+	}
+}
+
+// WithinTx runs fn in a transaction and commits it when fn returns nil. The
+// transaction travels in the context given to fn, where Conn finds it.
+//
+// Inside another WithinTx on the same pool fn runs in a savepoint of the
+// outer transaction: an error rolls back the work of fn only, and the
+// options are ignored since the outer transaction already fixed them.
+//
+// A transaction failing with a serialization failure or a deadlock is run
+// again from the start, so fn must not have side effects outside the
+// database it cannot repeat.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok && st.db == m.db {
+		return m.savepoint(ctx, st, fn)
+	}
+
+	cfg := txConfig{maxRetries: defaultTxRetries}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, span := trace.StartSpan(ctx, "db.Transaction", trace.WithAttributes(
+		attribute.String("db.transaction.isolation", cfg.opts.Isolation.String()),
+		attribute.Bool("db.transaction.read_only", cfg.opts.ReadOnly),
+	))
+	var err error
+	defer func() { trace.End(span, err) }()
+
+	backoff := m.backoffInitial
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("db.transaction.attempts", attempt))
+
+		err = m.run(ctx, cfg.opts, fn)
+		if err == nil || !IsRetryable(err) || attempt > cfg.maxRetries {
+			return err
+		}
+
+		span.AddEvent("retry", oteltrace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+		select {
+		case <-ctx.Done():
+			err = errors.Join(err, ctx.Err())
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, m.backoffMax)
+	}
+}
+
+// run is a single attempt of WithinTx.
+func (m *TxManager) run(ctx context.Context, opts sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTx(ctx, &opts)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{db: m.db, tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn in a savepoint of the transaction of st.
+func (m *TxManager) savepoint(ctx context.Context, st *txState, fn func(ctx context.Context) error) (err error) {
+	inner := &txState{db: st.db, tx: st.tx, depth: st.depth + 1}
+	name := fmt.Sprintf("sp_%d", inner.depth)
+
+	ctx, span := trace.StartSpan(ctx, "db.Savepoint", trace.WithAttributes(
+		attribute.String("db.savepoint", name),
+	))
+	defer func() { trace.End(span, err) }()
+
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, inner)); err != nil {
+		if _, rbErr := st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint: %w", rbErr))
+		}
+		return err
+	}
+
+	if _, err := st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
+
+// IsRetryable reports whether err is a serialization failure (SQLSTATE
+// 40001) or a deadlock (40P01), after which the whole transaction can be run
+// again.
+func IsRetryable(err error) bool {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return false
+	}
+	switch state.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
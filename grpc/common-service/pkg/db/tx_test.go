@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"common-service/pkg/tracetest"
+
+	"github.com/lib/pq"
+)
+
+// logDriver logs every statement, begin, commit and rollback of its
+// connections. Commits fail with the errors queued in commitErrs.
+type logDriver struct {
+	mu         sync.Mutex
+	log        []string
+	commitErrs []error
+}
+
+func (d *logDriver) Open(string) (driver.Conn, error) { return &logConn{d: d}, nil }
+
+func (d *logDriver) record(entry string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, entry)
+}
+
+func (d *logDriver) reset(commitErrs ...error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = nil
+	d.commitErrs = commitErrs
+}
+
+func (d *logDriver) entries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.log...)
+}
+
+type logConn struct{ d *logDriver }
+
+func (c *logConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *logConn) Close() error                        { return nil }
+func (c *logConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *logConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.record(fmt.Sprintf("BEGIN %s", sql.IsolationLevel(opts.Isolation)))
+	return &logTx{d: c.d}, nil
+}
+
+func (c *logConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+type logTx struct{ d *logDriver }
+
+func (t *logTx) Commit() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	t.d.log = append(t.d.log, "COMMIT")
+	if len(t.d.commitErrs) > 0 {
+		err := t.d.commitErrs[0]
+		t.d.commitErrs = t.d.commitErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (t *logTx) Rollback() error {
+	t.d.record("ROLLBACK")
+	return nil
+}
+
+var txLog = &logDriver{}
+
+func init() {
+	sql.Register("txlog", txLog)
+}
+
+func newTestTxManager(t *testing.T, commitErrs ...error) (*TxManager, *sql.DB) {
+	t.Helper()
+
+	txLog.reset(commitErrs...)
+	conn, err := sql.Open("txlog", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+
+	m := NewTxManager(conn)
+	m.backoffInitial = 0
+	return m, conn
+}
+
+func assertLog(t *testing.T, want ...string) {
+	t.Helper()
+	if got := txLog.entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTx_CommitsOnContextTx(t *testing.T) {
+	m, conn := newTestTxManager(t)
+
+	if InTx(context.Background(), conn) {
+		t.Fatal("background context is in a transaction")
+	}
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, ok := Conn(ctx, conn).(*sql.Tx); !ok {
+			t.Error("Conn does not return the transaction")
+		}
+		if _, ok := Conn(ctx, &sql.DB{}).(*sql.DB); !ok {
+			t.Error("Conn returns the transaction for another pool")
+		}
+		_, err := Conn(ctx, conn).ExecContext(ctx, "INSERT 1")
+		return err
+	}, WithIsolation(sql.LevelSerializable))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertLog(t, "BEGIN Serializable", "INSERT 1", "COMMIT")
+}
+
+func TestWithinTx_RollsBackOnError(t *testing.T) {
+	m, conn := newTestTxManager(t)
+	boom := errors.New("boom")
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		_, _ = Conn(ctx, conn).ExecContext(ctx, "INSERT 1")
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+
+	assertLog(t, "BEGIN Default", "INSERT 1", "ROLLBACK")
+}
+
+func TestWithinTx_NestedUsesSavepoints(t *testing.T) {
+	m, conn := newTestTxManager(t)
+	boom := errors.New("boom")
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		_, _ = Conn(ctx, conn).ExecContext(ctx, "INSERT 1")
+
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			_, _ = Conn(ctx, conn).ExecContext(ctx, "INSERT 2")
+			return m.WithinTx(ctx, func(ctx context.Context) error {
+				_, _ = Conn(ctx, conn).ExecContext(ctx, "INSERT 3")
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := m.WithinTx(ctx, func(ctx context.Context) error { return boom }); !errors.Is(err, boom) {
+			t.Errorf("nested err = %v, want boom", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertLog(t,
+		"BEGIN Default", "INSERT 1",
+		"SAVEPOINT sp_1", "INSERT 2",
+		"SAVEPOINT sp_2", "INSERT 3", "RELEASE SAVEPOINT sp_2",
+		"RELEASE SAVEPOINT sp_1",
+		"SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1",
+		"COMMIT",
+	)
+}
+
+func TestWithinTx_RetriesSerializationFailures(t *testing.T) {
+	rec := tracetest.Install(t)
+	m, _ := newTestTxManager(t, &pq.Error{Code: "40001"}, &pq.Error{Code: "40P01"})
+
+	runs := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs != 3 {
+		t.Errorf("runs = %d, want 3", runs)
+	}
+
+	rec.Span(t, "db.Transaction").
+		HasAttribute("db.transaction.attempts", 3).
+		HasEvent("retry")
+}
+
+func TestWithinTx_GivesUpAfterMaxRetries(t *testing.T) {
+	rec := tracetest.Install(t)
+	failure := &pq.Error{Code: "40001"}
+	m, _ := newTestTxManager(t, failure, failure, failure)
+
+	runs := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return nil
+	}, WithMaxRetries(1))
+	if !IsRetryable(err) {
+		t.Fatalf("err = %v, want the serialization failure", err)
+	}
+	if runs != 2 {
+		t.Errorf("runs = %d, want 2", runs)
+	}
+
+	rec.Span(t, "db.Transaction").HasError()
+}
+
+func TestWithinTx_DoesNotRetryOtherErrors(t *testing.T) {
+	m, _ := newTestTxManager(t, &pq.Error{Code: "23505"})
+
+	runs := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	if err == nil || IsRetryable(err) {
+		t.Fatalf("err = %v, want the unique violation", err)
+	}
+	if runs != 1 {
+		t.Errorf("runs = %d, want 1", runs)
+	}
+}
+
+func TestWithinTx_SavepointSpanIsChild(t *testing.T) {
+	rec := tracetest.Install(t)
+	m, _ := newTestTxManager(t)
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		return m.WithinTx(ctx, func(ctx context.Context) error { return nil })
+	}, ReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec.Span(t, "db.Transaction").
+		IsRoot().
+		HasAttribute("db.transaction.read_only", true).
+		Child("db.Savepoint").
+		HasAttribute("db.savepoint", "sp_1")
+}
@@ -0,0 +1,221 @@
+package grpcclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Clock abstracts time so the breaker can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+type CircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// FailureRatio trips the breaker once failures/requests in the current
+	// window reaches it.
+	FailureRatio float64 `mapstructure:"failure_ratio"`
+	// MinRequests is the number of calls a window needs before the ratio is
+	// evaluated.
+	MinRequests int `mapstructure:"min_requests"`
+	// Window is how long failures are counted while closed.
+	Window time.Duration `mapstructure:"window"`
+	// CoolDown is how long the breaker stays open before probing.
+	CoolDown time.Duration `mapstructure:"cool_down"`
+	// HalfOpenMaxRequests is the number of probes let through while half-open;
+	// that many consecutive successes close the breaker again.
+	HalfOpenMaxRequests int `mapstructure:"half_open_max_requests"`
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureRatio <= 0 || c.FailureRatio > 1 {
+		c.FailureRatio = 0.5
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = 10
+	}
+	if c.Window <= 0 {
+		c.Window = 30 * time.Second
+	}
+	if c.CoolDown <= 0 {
+		c.CoolDown = 10 * time.Second
+	}
+	if c.HalfOpenMaxRequests <= 0 {
+		c.HalfOpenMaxRequests = 1
+	}
+	return c
+}
+
+// Breaker is a closed/open/half-open circuit breaker.
+type Breaker struct {
+	mu    sync.Mutex
+	cfg   CircuitBreakerConfig
+	clock Clock
+
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+
+	onStateChange func(from, to State)
+}
+
+func NewBreaker(cfg CircuitBreakerConfig, clock Clock, onStateChange func(from, to State)) *Breaker {
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &Breaker{
+		cfg:           cfg.withDefaults(),
+		clock:         clock,
+		windowStart:   clock.Now(),
+		onStateChange: onStateChange,
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.clock.Now())
+	return b.state
+}
+
+// Allow reports whether a call may proceed. When it may, the returned done
+// function must be called with the outcome of the call.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	generation, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+	return func(success bool) { b.record(generation, success) }, nil
+}
+
+// allow is Allow returning the generation the outcome is recorded against,
+// with record or cancel.
+func (b *Breaker) allow() (time.Time, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.clock.Now())
+
+	switch b.state {
+	case StateOpen:
+		return time.Time{}, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenMaxRequests {
+			return time.Time{}, ErrCircuitOpen
+		}
+		b.probes++
+	}
+
+	return b.windowStart, nil
+}
+
+// cancel gives back a call let through by allow that never reached the
+// target, e.g. because the bulkhead was full. It records no outcome; a
+// half-open breaker can let another probe through instead.
+func (b *Breaker) cancel(generation time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.clock.Now())
+	if generation == b.windowStart && b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *Breaker) record(generation time.Time, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	b.refresh(now)
+
+	// outcomes of calls started in an earlier window or state are stale
+	if generation != b.windowStart {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.requests++
+		if !success {
+			b.failures++
+		}
+		if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.FailureRatio {
+			b.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if !success {
+			b.transition(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenMaxRequests {
+			b.transition(StateClosed, now)
+		}
+	}
+}
+
+// refresh applies time based transitions: the closed window rolling over and
+// the open state cooling down to half-open.
+func (b *Breaker) refresh(now time.Time) {
+	switch b.state {
+	case StateClosed:
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+	case StateOpen:
+		if now.Sub(b.openedAt) >= b.cfg.CoolDown {
+			b.transition(StateHalfOpen, now)
+		}
+	}
+}
+
+func (b *Breaker) transition(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.windowStart = now
+	b.requests, b.failures = 0, 0
+	b.probes, b.successes = 0, 0
+	if to == StateOpen {
+		b.openedAt = now
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
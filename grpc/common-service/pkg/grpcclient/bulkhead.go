@@ -0,0 +1,62 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+type BulkheadConfig struct {
+	// MaxConcurrent limits in-flight calls per method; 0 disables the limit.
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// MaxWait is how long a call may queue for a slot; 0 fails fast.
+	MaxWait time.Duration `mapstructure:"max_wait"`
+}
+
+// Bulkhead is a concurrency limiter isolating one downstream method from the
+// others.
+type Bulkhead struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+func NewBulkhead(cfg BulkheadConfig) *Bulkhead {
+	return &Bulkhead{
+		slots:   make(chan struct{}, cfg.MaxConcurrent),
+		maxWait: cfg.MaxWait,
+	}
+}
+
+// Acquire takes a slot, waiting up to MaxWait. The returned release function
+// gives the slot back.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-b.slots }
+
+	select {
+	case b.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if b.maxWait <= 0 {
+		return nil, ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrBulkheadFull
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
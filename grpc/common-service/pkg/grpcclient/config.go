@@ -0,0 +1,64 @@
+package grpcclient
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Config mirrors the clients.<name> block of the service configs.
+type Config struct {
+	Target    string          `mapstructure:"target" validate:"required"`
+	Port      int             `mapstructure:"port" validate:"omitempty,max=65535"`
+	TLS       TLSConfig       `mapstructure:"tls"`
+	Timeouts  TimeoutsConfig  `mapstructure:"timeouts"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+	Keepalive KeepaliveConfig `mapstructure:"keepalive"`
+
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Bulkhead       BulkheadConfig       `mapstructure:"bulkhead"`
+
+	// MeterProvider records the client RPC, circuit breaker and bulkhead
+	// metrics, the global one when nil.
+	MeterProvider metric.MeterProvider `mapstructure:"-"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type TimeoutsConfig struct {
+	// Dial bounds each connection attempt.
+	Dial time.Duration `mapstructure:"dial"`
+	// RPC is the default deadline of a call when the caller sets none.
+	RPC time.Duration `mapstructure:"rpc"`
+}
+
+type RetryConfig struct {
+	MaxAttempts          int           `mapstructure:"max_attempts"`
+	BackoffInitial       time.Duration `mapstructure:"backoff_initial"`
+	BackoffMax           time.Duration `mapstructure:"backoff_max"`
+	BackoffMultiplier    float64       `mapstructure:"backoff_multiplier"`
+	RetryableStatusCodes []string      `mapstructure:"retryable_status_codes"`
+}
+
+type KeepaliveConfig struct {
+	Time                time.Duration `mapstructure:"time"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	PermitWithoutStream bool          `mapstructure:"permit_without_stream"`
+}
+
+// Address returns the dial target. Targets that already carry a port or a
+// resolver scheme are used as is.
+func (c Config) Address() string {
+	if c.Port == 0 {
+		return c.Target
+	}
+	return fmt.Sprintf("%s:%d", c.Target, c.Port)
+}
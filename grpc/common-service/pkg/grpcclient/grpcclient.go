@@ -0,0 +1,214 @@
+package grpcclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// gRPC caps retry attempts at 5 regardless of the service config.
+	maxRetryAttempts         = 5
+	defaultBackoffInitial    = 100 * time.Millisecond
+	defaultBackoffMax        = time.Second
+	defaultBackoffMultiplier = 2.0
+)
+
+// NewClient creates a client connection configured from cfg. Extra options
+// are appended after the ones derived from the config.
+func NewClient(cfg Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	dialOpts, err := DialOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(cfg.Address(), append(dialOpts, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client for %s: %w", cfg.Address(), err)
+	}
+
+	return conn, nil
+}
+
+// DialOptions maps cfg to transport credentials, connect parameters,
+// keepalive, the default service config, the circuit breaker and bulkhead
+// interceptor and the otelgrpc client handler.
+func DialOptions(cfg Config) ([]grpc.DialOption, error) {
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceConfig, err := ServiceConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var handlerOpts []otelgrpc.Option
+	if cfg.MeterProvider != nil {
+		handlerOpts = append(handlerOpts, otelgrpc.WithMeterProvider(cfg.MeterProvider))
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(handlerOpts...)),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}
+
+	if cfg.Timeouts.Dial > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: cfg.Timeouts.Dial,
+		}))
+	}
+
+	if cfg.CircuitBreaker.Enabled || cfg.Bulkhead.MaxConcurrent > 0 {
+		interceptor, err := UnaryClientInterceptor(ResilienceOptions{
+			Target:         cfg.Address(),
+			CircuitBreaker: cfg.CircuitBreaker,
+			Bulkhead:       cfg.Bulkhead,
+			MeterProvider:  cfg.MeterProvider,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithChainUnaryInterceptor(interceptor))
+	}
+
+	if cfg.Keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive.Time,
+			Timeout:             cfg.Keepalive.Timeout,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}))
+	}
+
+	return opts, nil
+}
+
+type methodConfig struct {
+	Name        []map[string]string `json:"name"`
+	Timeout     string              `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy        `json:"retryPolicy,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// ServiceConfig renders the gRPC service config JSON applying the RPC
+// timeout and retry policy to every method of the target.
+func ServiceConfig(cfg Config) (string, error) {
+	mc := methodConfig{
+		// an empty name matches all services and methods
+		Name: []map[string]string{{}},
+	}
+
+	if cfg.Timeouts.RPC > 0 {
+		mc.Timeout = durationString(cfg.Timeouts.RPC)
+	}
+
+	if cfg.Retry.MaxAttempts > 1 {
+		policy, err := newRetryPolicy(cfg.Retry)
+		if err != nil {
+			return "", err
+		}
+		mc.RetryPolicy = policy
+	}
+
+	b, err := json.Marshal(map[string]any{"methodConfig": []methodConfig{mc}})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newRetryPolicy(cfg RetryConfig) (*retryPolicy, error) {
+	if len(cfg.RetryableStatusCodes) == 0 {
+		return nil, fmt.Errorf("retry: retryable_status_codes must not be empty")
+	}
+
+	statusCodes := make([]string, 0, len(cfg.RetryableStatusCodes))
+	for _, name := range cfg.RetryableStatusCodes {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+			return nil, fmt.Errorf("retry: unknown status code %q", name)
+		}
+		statusCodes = append(statusCodes, name)
+	}
+
+	policy := &retryPolicy{
+		MaxAttempts:          min(cfg.MaxAttempts, maxRetryAttempts),
+		InitialBackoff:       durationString(defaultBackoffInitial),
+		MaxBackoff:           durationString(defaultBackoffMax),
+		BackoffMultiplier:    defaultBackoffMultiplier,
+		RetryableStatusCodes: statusCodes,
+	}
+	if cfg.BackoffInitial > 0 {
+		policy.InitialBackoff = durationString(cfg.BackoffInitial)
+	}
+	if cfg.BackoffMax > 0 {
+		policy.MaxBackoff = durationString(cfg.BackoffMax)
+	}
+	if cfg.BackoffMultiplier > 0 {
+		policy.BackoffMultiplier = cfg.BackoffMultiplier
+	}
+
+	return policy, nil
+}
+
+func transportCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// durationString formats d the way the service config expects, e.g. "0.2s".
+func durationString(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
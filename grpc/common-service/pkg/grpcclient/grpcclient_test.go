@@ -0,0 +1,68 @@
+package grpcclient
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestServiceConfig(t *testing.T) {
+	cfg := Config{
+		Target:   "localhost",
+		Port:     50053,
+		Timeouts: TimeoutsConfig{Dial: 5 * time.Second, RPC: 2 * time.Second},
+		Retry: RetryConfig{
+			MaxAttempts:          3,
+			BackoffInitial:       200 * time.Millisecond,
+			BackoffMax:           2 * time.Second,
+			RetryableStatusCodes: []string{"UNAVAILABLE", "resource_exhausted"},
+		},
+	}
+
+	raw, err := ServiceConfig(cfg)
+	if err != nil {
+		t.Fatalf("ServiceConfig() error = %v", err)
+	}
+
+	var got struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}
+	if err := json.Unmarshal([]byte(raw), &got); err != nil {
+		t.Fatalf("invalid service config %s: %v", raw, err)
+	}
+
+	mc := got.MethodConfig[0]
+	if mc.Timeout != "2s" {
+		t.Errorf("timeout = %q, want %q", mc.Timeout, "2s")
+	}
+	if mc.RetryPolicy == nil {
+		t.Fatal("retry policy missing")
+	}
+	if mc.RetryPolicy.MaxAttempts != 3 || mc.RetryPolicy.InitialBackoff != "0.2s" || mc.RetryPolicy.MaxBackoff != "2s" {
+		t.Errorf("retry policy = %+v", mc.RetryPolicy)
+	}
+	if codes := mc.RetryPolicy.RetryableStatusCodes; len(codes) != 2 || codes[1] != "RESOURCE_EXHAUSTED" {
+		t.Errorf("retryable codes = %v", codes)
+	}
+
+	if _, err := NewClient(cfg); err != nil {
+		t.Errorf("NewClient() error = %v", err)
+	}
+}
+
+func TestServiceConfig_InvalidStatusCode(t *testing.T) {
+	_, err := ServiceConfig(Config{Retry: RetryConfig{MaxAttempts: 2, RetryableStatusCodes: []string{"NOPE"}}})
+	if err == nil {
+		t.Fatal("expected error for unknown status code")
+	}
+}
+
+func TestServiceConfig_NoRetry(t *testing.T) {
+	raw, err := ServiceConfig(Config{Retry: RetryConfig{MaxAttempts: 1}})
+	if err != nil {
+		t.Fatalf("ServiceConfig() error = %v", err)
+	}
+	if raw != `{"methodConfig":[{"name":[{}]}]}` {
+		t.Errorf("service config = %s", raw)
+	}
+}
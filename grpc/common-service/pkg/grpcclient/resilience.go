@@ -0,0 +1,219 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const meterName = "common-service/pkg/grpcclient"
+
+// ResilienceOptions configures the circuit breaker and bulkhead interceptor.
+type ResilienceOptions struct {
+	Target         string
+	CircuitBreaker CircuitBreakerConfig
+	Bulkhead       BulkheadConfig
+	Clock          Clock
+	// MeterProvider records the breaker and bulkhead metrics, the global
+	// one when nil.
+	MeterProvider metric.MeterProvider
+}
+
+type methodGuard struct {
+	breaker  *Breaker
+	bulkhead *Bulkhead
+}
+
+type resilience struct {
+	opts   ResilienceOptions
+	mu     sync.Mutex
+	guards map[string]*methodGuard
+
+	transitions metric.Int64Counter
+	rejections  metric.Int64Counter
+	inFlight    metric.Int64UpDownCounter
+}
+
+// UnaryClientInterceptor guards every method of the target with its own
+// circuit breaker and bulkhead. Rejected calls fail with codes.Unavailable
+// (breaker open) or codes.ResourceExhausted (bulkhead full) without reaching
+// the network.
+func UnaryClientInterceptor(opts ResilienceOptions) (grpc.UnaryClientInterceptor, error) {
+	r, err := newResilience(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.intercept, nil
+}
+
+func newResilience(opts ResilienceOptions) (*resilience, error) {
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	r := &resilience{
+		opts:   opts,
+		guards: map[string]*methodGuard{},
+	}
+
+	meter := opts.MeterProvider.Meter(meterName)
+
+	var err error
+	if r.transitions, err = meter.Int64Counter("grpc.client.circuit_breaker.transitions",
+		metric.WithDescription("Circuit breaker state transitions"),
+	); err != nil {
+		return nil, err
+	}
+	if r.rejections, err = meter.Int64Counter("grpc.client.rejected_calls",
+		metric.WithDescription("Calls rejected by the circuit breaker or bulkhead"),
+	); err != nil {
+		return nil, err
+	}
+	if r.inFlight, err = meter.Int64UpDownCounter("grpc.client.bulkhead.in_flight",
+		metric.WithDescription("Calls holding a bulkhead slot"),
+	); err != nil {
+		return nil, err
+	}
+
+	_, err = meter.Int64ObservableGauge("grpc.client.circuit_breaker.state",
+		metric.WithDescription("Circuit breaker state: 0 closed, 1 open, 2 half-open"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for method, g := range r.guards {
+				if g.breaker != nil {
+					o.Observe(int64(g.breaker.State()), metric.WithAttributes(r.attrs(method)...))
+				}
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *resilience) attrs(method string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.target", r.opts.Target),
+		attribute.String("rpc.method", method),
+	}
+}
+
+func (r *resilience) guard(method string) *methodGuard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g, ok := r.guards[method]; ok {
+		return g
+	}
+
+	g := &methodGuard{}
+	if r.opts.CircuitBreaker.Enabled {
+		attrs := r.attrs(method)
+		g.breaker = NewBreaker(r.opts.CircuitBreaker, r.opts.Clock, func(from, to State) {
+			r.transitions.Add(context.Background(), 1, metric.WithAttributes(
+				append(attrs, attribute.String("from", from.String()), attribute.String("to", to.String()))...,
+			))
+		})
+	}
+	if r.opts.Bulkhead.MaxConcurrent > 0 {
+		g.bulkhead = NewBulkhead(r.opts.Bulkhead)
+	}
+	r.guards[method] = g
+
+	return g
+}
+
+func (r *resilience) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	g := r.guard(method)
+	span := trace.SpanFromContext(ctx)
+	attrs := r.attrs(method)
+
+	var generation time.Time
+	if g.breaker != nil {
+		before := g.breaker.State()
+
+		var err error
+		generation, err = g.breaker.allow()
+		if err != nil {
+			r.reject(ctx, span, "circuit_breaker", attrs)
+			return status.Errorf(codes.Unavailable, "%s: %v", method, err)
+		}
+
+		if after := g.breaker.State(); after != before {
+			span.AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+				append(attrs, attribute.String("from", before.String()), attribute.String("to", after.String()))...,
+			))
+		}
+	}
+
+	if g.bulkhead != nil {
+		release, err := g.bulkhead.Acquire(ctx)
+		if err != nil {
+			if g.breaker != nil {
+				// the call never happened, it says nothing about the target
+				g.breaker.cancel(generation)
+			}
+			if errors.Is(err, ErrBulkheadFull) {
+				r.reject(ctx, span, "bulkhead", attrs)
+				return status.Errorf(codes.ResourceExhausted, "%s: %v", method, err)
+			}
+			return status.FromContextError(err).Err()
+		}
+		r.inFlight.Add(ctx, 1, metric.WithAttributes(attrs...))
+		defer func() {
+			release()
+			r.inFlight.Add(ctx, -1, metric.WithAttributes(attrs...))
+		}()
+	}
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	if g.breaker != nil {
+		before := g.breaker.State()
+		g.breaker.record(generation, !isFailure(err))
+		if after := g.breaker.State(); after != before {
+			span.AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+				append(attrs, attribute.String("from", before.String()), attribute.String("to", after.String()))...,
+			))
+		}
+	}
+
+	return err
+}
+
+func (r *resilience) reject(ctx context.Context, span trace.Span, reason string, attrs []attribute.KeyValue) {
+	attrs = append(attrs, attribute.String("reason", reason))
+	r.rejections.Add(ctx, 1, metric.WithAttributes(attrs...))
+	span.AddEvent(reason+".rejected", trace.WithAttributes(attrs...))
+}
+
+// isFailure reports whether err says something about the health of the
+// target. Errors caused by the request itself do not trip the breaker.
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
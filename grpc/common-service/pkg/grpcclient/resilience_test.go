@@ -0,0 +1,374 @@
+package grpcclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func call(t *testing.T, b *Breaker, success bool) error {
+	t.Helper()
+
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	done(success)
+	return nil
+}
+
+func TestBreaker_Transitions(t *testing.T) {
+	clock := newFakeClock()
+	var transitions []string
+	b := NewBreaker(CircuitBreakerConfig{
+		FailureRatio:        0.5,
+		MinRequests:         4,
+		Window:              time.Minute,
+		CoolDown:            10 * time.Second,
+		HalfOpenMaxRequests: 2,
+	}, clock, func(from, to State) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	// 1 failure out of 3 stays below MinRequests
+	call(t, b, true)
+	call(t, b, false)
+	call(t, b, true)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+
+	// 2 failures out of 4 reaches the ratio
+	call(t, b, false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if err := call(t, b, true); err != ErrCircuitOpen {
+		t.Fatalf("Allow() while open error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// cool-down elapses, probes are limited to HalfOpenMaxRequests
+	clock.Advance(10 * time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want half_open", b.State())
+	}
+	done1, err := b.Allow()
+	if err != nil {
+		t.Fatalf("first probe rejected: %v", err)
+	}
+	done2, err := b.Allow()
+	if err != nil {
+		t.Fatalf("second probe rejected: %v", err)
+	}
+	if _, err := b.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("third probe error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// a failed probe re-opens the breaker
+	done1(false)
+	done2(true)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+
+	// successful probes close it again
+	clock.Advance(10 * time.Second)
+	call(t, b, true)
+	call(t, b, true)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+
+	want := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition[%d] = %s, want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestBreaker_WindowResets(t *testing.T) {
+	clock := newFakeClock()
+	b := NewBreaker(CircuitBreakerConfig{FailureRatio: 0.5, MinRequests: 2, Window: time.Second}, clock, nil)
+
+	call(t, b, false)
+	clock.Advance(time.Second)
+	call(t, b, false)
+	if b.State() != StateClosed {
+		t.Fatalf("failures from an expired window tripped the breaker")
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	clock := newFakeClock()
+	intercept, err := UnaryClientInterceptor(ResilienceOptions{
+		Target:         "product-service:50053",
+		CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureRatio: 0.4, MinRequests: 5, CoolDown: time.Second},
+		Clock:          clock,
+	})
+	if err != nil {
+		t.Fatalf("UnaryClientInterceptor() error = %v", err)
+	}
+
+	calls := 0
+	failing := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "down")
+	}
+	invalid := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.InvalidArgument, "bad request")
+	}
+
+	const getProduct = "/product.ProductService/GetProduct"
+	const listProducts = "/product.ProductService/ListProducts"
+
+	// client errors do not count as failures
+	for i := 0; i < 3; i++ {
+		intercept(context.Background(), getProduct, nil, nil, nil, invalid)
+	}
+	for i := 0; i < 2; i++ {
+		intercept(context.Background(), getProduct, nil, nil, nil, failing)
+	}
+	if calls != 5 {
+		t.Fatalf("invoker calls = %d, want 5", calls)
+	}
+
+	err = intercept(context.Background(), getProduct, nil, nil, nil, failing)
+	if status.Code(err) != codes.Unavailable || calls != 5 {
+		t.Fatalf("open breaker: err = %v, calls = %d", err, calls)
+	}
+
+	// breakers are per method
+	intercept(context.Background(), listProducts, nil, nil, nil, failing)
+	if calls != 6 {
+		t.Fatalf("other method was blocked by the open breaker")
+	}
+}
+
+func TestUnaryClientInterceptor_Bulkhead(t *testing.T) {
+	intercept, err := UnaryClientInterceptor(ResilienceOptions{
+		Target:   "user-service:50051",
+		Bulkhead: BulkheadConfig{MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatalf("UnaryClientInterceptor() error = %v", err)
+	}
+
+	const method = "/user.UserService/CreateUser"
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		close(entered)
+		<-release
+		return nil
+	}
+	ok := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- intercept(context.Background(), method, nil, nil, nil, blocking) }()
+	<-entered
+
+	if err := intercept(context.Background(), method, nil, nil, nil, ok); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call err = %v, want ResourceExhausted", err)
+	}
+
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatalf("first call err = %v", err)
+	}
+	if err := intercept(context.Background(), method, nil, nil, nil, ok); err != nil {
+		t.Fatalf("call after release err = %v", err)
+	}
+}
+
+func TestUnaryClientInterceptor_BulkheadRejectionsLeaveBreakerAlone(t *testing.T) {
+	clock := newFakeClock()
+	r, err := newResilience(ResilienceOptions{
+		Target:         "product-service:50053",
+		CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureRatio: 1, MinRequests: 1, CoolDown: time.Second, HalfOpenMaxRequests: 2},
+		Bulkhead:       BulkheadConfig{MaxConcurrent: 1},
+		Clock:          clock,
+	})
+	if err != nil {
+		t.Fatalf("newResilience() error = %v", err)
+	}
+
+	const method = "/product.ProductService/GetProduct"
+	failing := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "down")
+	}
+	ok := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		close(entered)
+		<-release
+		return nil
+	}
+
+	_ = r.intercept(context.Background(), method, nil, nil, nil, failing)
+	clock.Advance(time.Second)
+	breaker := r.guard(method).breaker
+	if got := breaker.State(); got != StateHalfOpen {
+		t.Fatalf("state = %s, want half_open", got)
+	}
+
+	// the first probe holds the only bulkhead slot
+	errc := make(chan error, 1)
+	go func() { errc <- r.intercept(context.Background(), method, nil, nil, nil, blocking) }()
+	<-entered
+
+	for i := range 3 {
+		if err := r.intercept(context.Background(), method, nil, nil, nil, ok); status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("call %d err = %v, want ResourceExhausted from the bulkhead", i, err)
+		}
+	}
+	if got := breaker.State(); got != StateHalfOpen {
+		t.Fatalf("state = %s after bulkhead rejections, want half_open", got)
+	}
+
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if got := breaker.State(); got != StateHalfOpen {
+		t.Fatalf("state = %s after one of two probes, want half_open", got)
+	}
+	if err := r.intercept(context.Background(), method, nil, nil, nil, ok); err != nil {
+		t.Fatalf("second probe err = %v", err)
+	}
+	if got := breaker.State(); got != StateClosed {
+		t.Fatalf("state = %s after two successful probes, want closed", got)
+	}
+}
+
+func TestUnaryClientInterceptor_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	intercept, err := UnaryClientInterceptor(ResilienceOptions{
+		Target:         "product-service:50053",
+		CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureRatio: 0.5, MinRequests: 2, CoolDown: time.Minute},
+		Bulkhead:       BulkheadConfig{MaxConcurrent: 1},
+		Clock:          newFakeClock(),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	if err != nil {
+		t.Fatalf("UnaryClientInterceptor() error = %v", err)
+	}
+
+	const method = "/product.ProductService/GetProduct"
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		close(entered)
+		<-release
+		return nil
+	}
+	failing := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "down")
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- intercept(context.Background(), method, nil, nil, nil, blocking) }()
+	<-entered
+
+	if got := metricValue(t, reader, "grpc.client.bulkhead.in_flight"); got != 1 {
+		t.Errorf("in_flight while a call holds the slot = %d, want 1", got)
+	}
+	if err := intercept(context.Background(), method, nil, nil, nil, failing); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("call with the bulkhead full err = %v, want ResourceExhausted", err)
+	}
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatalf("first call err = %v", err)
+	}
+
+	_ = intercept(context.Background(), method, nil, nil, nil, failing)
+	if err := intercept(context.Background(), method, nil, nil, nil, failing); status.Code(err) != codes.Unavailable {
+		t.Fatalf("call with the breaker open err = %v, want Unavailable", err)
+	}
+
+	if got := metricValue(t, reader, "grpc.client.bulkhead.in_flight"); got != 0 {
+		t.Errorf("in_flight after the calls = %d, want 0", got)
+	}
+	if got := metricValue(t, reader, "grpc.client.rejected_calls", attribute.String("reason", "bulkhead")); got != 1 {
+		t.Errorf("bulkhead rejections = %d, want 1", got)
+	}
+	if got := metricValue(t, reader, "grpc.client.rejected_calls", attribute.String("reason", "circuit_breaker")); got != 1 {
+		t.Errorf("circuit breaker rejections = %d, want 1", got)
+	}
+	if got := metricValue(t, reader, "grpc.client.circuit_breaker.transitions",
+		attribute.String("from", "closed"), attribute.String("to", "open")); got != 1 {
+		t.Errorf("closed->open transitions = %d, want 1", got)
+	}
+	if got := metricValue(t, reader, "grpc.client.circuit_breaker.state", attribute.String("rpc.method", method)); got != int64(StateOpen) {
+		t.Errorf("breaker state = %d, want %d", got, StateOpen)
+	}
+}
+
+// metricValue collects reader and returns the value of the int64 metric
+// name summed over the points carrying all of attrs.
+func metricValue(t *testing.T, reader *sdkmetric.ManualReader, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			var points []metricdata.DataPoint[int64]
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				points = data.DataPoints
+			case metricdata.Gauge[int64]:
+				points = data.DataPoints
+			default:
+				t.Fatalf("%s = %+v, want int64 points", name, m.Data)
+			}
+
+			var sum int64
+		points:
+			for _, p := range points {
+				for _, a := range attrs {
+					if v, ok := p.Attributes.Value(a.Key); !ok || v != a.Value {
+						continue points
+					}
+				}
+				sum += p.Value
+			}
+			return sum
+		}
+	}
+	t.Fatalf("metric %s was not recorded", name)
+	return 0
+}
@@ -0,0 +1,67 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Timeout is a default RPC deadline that can be changed while a connection
+// is open, unlike the timeout of the service config.
+type Timeout struct {
+	d atomic.Int64
+}
+
+func NewTimeout(d time.Duration) *Timeout {
+	t := &Timeout{}
+	t.Set(d)
+	return t
+}
+
+func (t *Timeout) Get() time.Duration {
+	return time.Duration(t.d.Load())
+}
+
+// Set changes the deadline of later calls. Zero means none.
+func (t *Timeout) Set(d time.Duration) {
+	t.d.Store(int64(d))
+}
+
+// UnaryClientInterceptor sets the current timeout on calls whose context
+// has no deadline.
+func (t *Timeout) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			if d := t.Get(); d > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d)
+				defer cancel()
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// NewClientWithTimeout is NewClient with Timeouts.RPC applied by an
+// outermost interceptor instead of the service config, so that it can be
+// changed through the returned Timeout, e.g. on a config reload.
+func NewClientWithTimeout(cfg Config, opts ...grpc.DialOption) (*grpc.ClientConn, *Timeout, error) {
+	timeout := NewTimeout(cfg.Timeouts.RPC)
+	cfg.Timeouts.RPC = 0
+
+	dialOpts, err := DialOptions(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialOpts = append([]grpc.DialOption{grpc.WithChainUnaryInterceptor(timeout.UnaryClientInterceptor())}, dialOpts...)
+
+	conn, err := grpc.NewClient(cfg.Address(), append(dialOpts, opts...)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create grpc client for %s: %w", cfg.Address(), err)
+	}
+
+	return conn, timeout, nil
+}
@@ -0,0 +1,59 @@
+package grpcclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestTimeout_UnaryClientInterceptor(t *testing.T) {
+	timeout := NewTimeout(time.Second)
+	intercept := timeout.UnaryClientInterceptor()
+
+	deadline := func(ctx context.Context) time.Duration {
+		var left time.Duration
+		invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			if d, ok := ctx.Deadline(); ok {
+				left = time.Until(d)
+			}
+			return nil
+		}
+		if err := intercept(ctx, "/x/Y", nil, nil, nil, invoker); err != nil {
+			t.Fatal(err)
+		}
+		return left
+	}
+
+	if got := deadline(context.Background()); got <= 0 || got > time.Second {
+		t.Errorf("deadline in %v, want at most 1s", got)
+	}
+
+	timeout.Set(time.Minute)
+	if got := deadline(context.Background()); got <= time.Second {
+		t.Errorf("deadline in %v after Set, want about a minute", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if got := deadline(ctx); got > 10*time.Millisecond {
+		t.Errorf("deadline in %v, want the caller's kept", got)
+	}
+
+	timeout.Set(0)
+	if got := deadline(context.Background()); got != 0 {
+		t.Errorf("deadline in %v, want none", got)
+	}
+}
+
+func TestNewClientWithTimeout(t *testing.T) {
+	conn, timeout, err := NewClientWithTimeout(Config{Target: "localhost", Port: 50053, Timeouts: TimeoutsConfig{RPC: 2 * time.Second}})
+	if err != nil {
+		t.Fatalf("NewClientWithTimeout() error = %v", err)
+	}
+	defer conn.Close()
+	if timeout.Get() != 2*time.Second {
+		t.Errorf("timeout = %v, want 2s", timeout.Get())
+	}
+}
@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const defaultCleanupInterval = 10 * time.Minute
+
+// Expirer is implemented by stores that keep expired records until they are
+// deleted, such as PostgresStore.
+type Expirer interface {
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// RunCleanup deletes expired records every interval until ctx is cancelled.
+// A zero interval means every ten minutes.
+func RunCleanup(ctx context.Context, store Expirer, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := store.DeleteExpired(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete expired idempotency keys", "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.DebugContext(ctx, "Deleted expired idempotency keys", "count", n)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingExpirer struct {
+	calls atomic.Int32
+}
+
+func (e *countingExpirer) DeleteExpired(context.Context) (int64, error) {
+	if e.calls.Add(1) == 1 {
+		return 0, errors.New("database unavailable")
+	}
+	return 1, nil
+}
+
+func TestRunCleanup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := &countingExpirer{}
+
+	done := make(chan struct{})
+	go func() {
+		RunCleanup(ctx, store, 10*time.Millisecond)
+		close(done)
+	}()
+
+	// a failed run does not stop the loop
+	deadline := time.Now().Add(time.Second)
+	for store.calls.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("DeleteExpired called %d times, want 3", store.calls.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunCleanup did not return after cancel")
+	}
+}
@@ -0,0 +1,211 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/textproto"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	// MetadataKey carries the idempotency key in gRPC metadata. Over HTTP the
+	// grpc-gateway maps the Idempotency-Key header to it with
+	// GatewayHeaderMatcher.
+	MetadataKey = "idempotency-key"
+
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "idempotency-replayed"
+
+	defaultTTL   = 24 * time.Hour
+	defaultLease = time.Minute
+	maxKeyLength = 255
+)
+
+type Options struct {
+	Store Store
+	// Methods lists the full method names that honour idempotency keys.
+	Methods []string
+	// TTL is how long a completed outcome is replayed.
+	TTL time.Duration
+	// Lease is how long a call may hold a key before it is considered lost,
+	// e.g. with a crashed process, and another call takes the key over. It
+	// must exceed the longest time the handler runs.
+	Lease time.Duration
+}
+
+// UnaryServerInterceptor makes the configured methods idempotent. The first
+// call with a key runs the handler and its status and response are stored;
+// later calls with the same key and request get the stored outcome, while
+// the same key with a different request is rejected with
+// codes.InvalidArgument.
+//
+// A failed handler may already have committed its changes, so its outcome
+// is stored like any other. Only codes.ResourceExhausted, which the rate
+// limiter and load shedding return without running the call, releases the
+// key for a retry. Chain the interceptor after those, so that every other
+// outcome it sees comes from the handler, and make handlers map downstream
+// rejections to another code once they have made changes.
+func UnaryServerInterceptor(opts Options) grpc.UnaryServerInterceptor {
+	methods := make(map[string]bool, len(opts.Methods))
+	for _, m := range opts.Methods {
+		methods[m] = true
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	lease := opts.Lease
+	if lease <= 0 {
+		lease = defaultLease
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		key := KeyFromIncomingContext(ctx)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		if len(key) > maxKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency key longer than %d characters", maxKeyLength)
+		}
+
+		hash, err := requestHash(req)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "hash request: %v", err)
+		}
+
+		rec := &Record{
+			Key:         info.FullMethod + ":" + key,
+			Method:      info.FullMethod,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(lease),
+		}
+
+		existing, err := opts.Store.Reserve(ctx, rec)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "idempotency store: %v", err)
+		}
+		if existing != nil {
+			return replay(ctx, existing, hash)
+		}
+
+		resp, err := handler(ctx, req)
+
+		st := status.Convert(err)
+		if err != nil && st.Code() == codes.ResourceExhausted {
+			if rerr := opts.Store.Release(context.WithoutCancel(ctx), rec.Key); rerr != nil {
+				slog.ErrorContext(ctx, "Failed to release idempotency key", "key", key, "error", rerr)
+			}
+			return resp, err
+		}
+
+		rec.Code = st.Code()
+		rec.Message = st.Message()
+		rec.ExpiresAt = time.Now().Add(ttl)
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			b, merr := proto.Marshal(msg)
+			if merr != nil {
+				// the handler ran, so replays report the failure rather than
+				// running it again
+				slog.ErrorContext(ctx, "Failed to marshal response for idempotency store", "error", merr)
+				rec.Code = codes.Internal
+				rec.Message = "the response of the original request could not be stored"
+			} else {
+				rec.ResponseType = string(msg.ProtoReflect().Descriptor().FullName())
+				rec.Response = b
+			}
+		}
+
+		if cerr := opts.Store.Complete(context.WithoutCancel(ctx), rec); cerr != nil {
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "key", key, "error", cerr)
+		}
+
+		return resp, err
+	}
+}
+
+func replay(ctx context.Context, rec *Record, hash string) (any, error) {
+	if rec.RequestHash != hash {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+	}
+	if !rec.Completed {
+		return nil, status.Error(codes.Aborted, "a request with this idempotency key is still in progress")
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true"))
+
+	if rec.Code != codes.OK {
+		return nil, status.Error(rec.Code, rec.Message)
+	}
+
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(rec.ResponseType))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown stored response type %q", rec.ResponseType)
+	}
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(rec.Response, msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode stored response: %v", err)
+	}
+	return msg, nil
+}
+
+// KeyFromIncomingContext returns the idempotency key of the call, if any.
+func KeyFromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(MetadataKey); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// ForwardKeyClientInterceptor copies the incoming idempotency key onto
+// outgoing calls so a retried request is idempotent downstream as well.
+func ForwardKeyClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if key := KeyFromIncomingContext(ctx); key != "" {
+			if md, ok := metadata.FromOutgoingContext(ctx); !ok || len(md.Get(MetadataKey)) == 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, key)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// GatewayHeaderMatcher is a runtime.WithIncomingHeaderMatcher function that
+// forwards the Idempotency-Key HTTP header on top of the gateway defaults.
+func GatewayHeaderMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == "Idempotency-Key" {
+		return MetadataKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func requestHash(req any) (string, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", nil
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,151 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const method = "/user.UserService/CreateUser"
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	intercept := UnaryServerInterceptor(Options{Store: NewMemoryStore(), Methods: []string{method}})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return wrapperspb.String("created " + req.(*wrapperspb.StringValue).GetValue()), nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "abc"))
+
+	first, err := intercept(ctx, wrapperspb.String("alice"), info, handler)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	second, err := intercept(ctx, wrapperspb.String("alice"), info, handler)
+	if err != nil {
+		t.Fatalf("repeat call: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		t.Errorf("replayed response = %v, want %v", second, first)
+	}
+
+	_, err = intercept(ctx, wrapperspb.String("bob"), info, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("different request with same key err = %v, want InvalidArgument", err)
+	}
+
+	// calls without a key or on other methods are untouched
+	intercept(context.Background(), wrapperspb.String("alice"), info, handler)
+	intercept(ctx, wrapperspb.String("alice"), &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUser"}, handler)
+	if calls != 3 {
+		t.Errorf("handler calls = %d, want 3", calls)
+	}
+}
+
+func TestUnaryServerInterceptor_Errors(t *testing.T) {
+	intercept := UnaryServerInterceptor(Options{Store: NewMemoryStore(), Methods: []string{method}})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	calls := 0
+	code := codes.ResourceExhausted
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return nil, status.Error(code, "boom")
+	}
+
+	// a call refused before running is not remembered
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k1"))
+	intercept(ctx, wrapperspb.String("x"), info, handler)
+	intercept(ctx, wrapperspb.String("x"), info, handler)
+	if calls != 2 {
+		t.Fatalf("handler calls after ResourceExhausted = %d, want 2", calls)
+	}
+
+	// any other failure may follow committed changes and is replayed
+	for i, c := range []codes.Code{codes.AlreadyExists, codes.Unavailable, codes.Internal, codes.DeadlineExceeded} {
+		code = c
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, c.String()))
+		intercept(ctx, wrapperspb.String("x"), info, handler)
+		_, err := intercept(ctx, wrapperspb.String("x"), info, handler)
+		if calls != 3+i || status.Code(err) != c {
+			t.Fatalf("calls = %d, err = %v; want %d, %s", calls, err, 3+i, c)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor_LeaseExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	intercept := UnaryServerInterceptor(Options{Store: store, Methods: []string{method}, Lease: time.Minute})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k3"))
+
+	// a reservation left behind by a crashed process
+	hash, _ := requestHash(wrapperspb.String("x"))
+	if _, err := store.Reserve(ctx, &Record{Key: method + ":k3", Method: method, RequestHash: hash, ExpiresAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(ctx context.Context, req any) (any, error) { return wrapperspb.String("created"), nil }
+	if _, err := intercept(ctx, wrapperspb.String("x"), info, handler); status.Code(err) != codes.Aborted {
+		t.Fatalf("call within the lease err = %v, want Aborted", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := intercept(ctx, wrapperspb.String("x"), info, handler); err != nil {
+		t.Fatalf("call after the lease: %v", err)
+	}
+
+	// the completed outcome outlives the lease
+	now = now.Add(2 * time.Minute)
+	resp, err := intercept(ctx, wrapperspb.String("x"), info, func(context.Context, any) (any, error) {
+		t.Fatal("handler ran again")
+		return nil, nil
+	})
+	if err != nil || resp.(*wrapperspb.StringValue).GetValue() != "created" {
+		t.Errorf("replay = %v, %v; want the stored response", resp, err)
+	}
+}
+
+func TestUnaryServerInterceptor_InProgress(t *testing.T) {
+	store := NewMemoryStore()
+	intercept := UnaryServerInterceptor(Options{Store: store, Methods: []string{method}})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k2"))
+
+	var inner error
+	handler := func(ctx context.Context, req any) (any, error) {
+		_, inner = intercept(ctx, req, info, func(context.Context, any) (any, error) { return wrapperspb.String("nested"), nil })
+		return wrapperspb.String("outer"), nil
+	}
+
+	if _, err := intercept(ctx, wrapperspb.String("x"), info, handler); err != nil {
+		t.Fatalf("outer call: %v", err)
+	}
+	if status.Code(inner) != codes.Aborted {
+		t.Errorf("concurrent call err = %v, want Aborted", inner)
+	}
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	if got, ok := GatewayHeaderMatcher("Idempotency-Key"); !ok || got != MetadataKey {
+		t.Errorf("Idempotency-Key mapped to %q, %v", got, ok)
+	}
+	if _, ok := GatewayHeaderMatcher("X-Unrelated"); ok {
+		t.Error("unrelated header was forwarded")
+	}
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
+
+// PostgresStore keeps records in a table created by the service migrations:
+//
+//	CREATE TABLE idempotency_keys (
+//	    key TEXT PRIMARY KEY,
+//	    method TEXT NOT NULL,
+//	    request_hash TEXT NOT NULL,
+//	    completed BOOLEAN NOT NULL DEFAULT false,
+//	    status_code INT,
+//	    status_message TEXT,
+//	    response_type TEXT,
+//	    response BYTEA,
+//	    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+//	    expires_at TIMESTAMPTZ NOT NULL
+//	);
+//
+// An in-progress row expires when its lease runs out and a completed row
+// when its TTL does. Expired rows are taken over by Reserve but otherwise stay until
+// DeleteExpired removes them; see RunCleanup.
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+func NewPostgresStore(db *sql.DB, table string) *PostgresStore {
+	if table == "" {
+		table = "idempotency_keys"
+	}
+	return &PostgresStore{db: db, table: table}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, rec *Record) (*Record, error) {
+	// an expired row is taken over as if it did not exist
+	query := fmt.Sprintf(`INSERT INTO %s (key, method, request_hash, expires_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (key) DO UPDATE SET
+    method = EXCLUDED.method,
+    request_hash = EXCLUDED.request_hash,
+    completed = false,
+    status_code = NULL,
+    status_message = NULL,
+    response_type = NULL,
+    response = NULL,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+WHERE %s.expires_at <= CURRENT_TIMESTAMP
+RETURNING key`, s.table, s.table)
+
+	var key string
+	err := s.db.QueryRowContext(ctx, query, rec.Key, rec.Method, rec.RequestHash, rec.ExpiresAt).Scan(&key)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var (
+		existing     Record
+		code         sql.NullInt32
+		message      sql.NullString
+		responseType sql.NullString
+	)
+	err = s.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT key, method, request_hash, completed, status_code, status_message, response_type, response, expires_at FROM %s WHERE key = $1", s.table),
+		rec.Key,
+	).Scan(&existing.Key, &existing.Method, &existing.RequestHash, &existing.Completed, &code, &message, &responseType, &existing.Response, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the holder released the key between the two statements; report it
+		// as still in progress so the client retries
+		return &Record{Key: rec.Key, Method: rec.Method, RequestHash: rec.RequestHash, ExpiresAt: rec.ExpiresAt}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing.Code = codes.Code(code.Int32)
+	existing.Message = message.String
+	existing.ResponseType = responseType.String
+
+	return &existing, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, rec *Record) error {
+	_, err := s.db.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET completed = true, status_code = $2, status_message = $3, response_type = $4, response = $5, expires_at = $6 WHERE key = $1", s.table),
+		rec.Key, int32(rec.Code), rec.Message, rec.ResponseType, rec.Response, rec.ExpiresAt,
+	)
+	return err
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE key = $1 AND completed = false", s.table),
+		key,
+	)
+	return err
+}
+
+// DeleteExpired removes expired records and returns how many were removed.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at <= CURRENT_TIMESTAMP", s.table),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testStore creates a fresh idempotency table in E2E_POSTGRES_DSN, skipping
+// the test when the variable is not set.
+func testStore(t *testing.T) *PostgresStore {
+	t.Helper()
+
+	dsn := os.Getenv("E2E_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("E2E_POSTGRES_DSN is not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	table := fmt.Sprintf("idempotency_test_%d", time.Now().UnixNano())
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %s (
+    key TEXT PRIMARY KEY,
+    method TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT false,
+    status_code INT,
+    status_message TEXT,
+    response_type TEXT,
+    response BYTEA,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL
+)`, table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = conn.ExecContext(ctx, "DROP TABLE "+table)
+		_ = conn.Close()
+	})
+
+	return NewPostgresStore(conn, table)
+}
+
+func TestPostgresStore(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	rec := &Record{Key: "k1", Method: method, RequestHash: "h1", ExpiresAt: time.Now().Add(time.Hour)}
+	if existing, err := store.Reserve(ctx, rec); err != nil || existing != nil {
+		t.Fatalf("Reserve = %+v, %v, want a new reservation", existing, err)
+	}
+
+	existing, err := store.Reserve(ctx, rec)
+	if err != nil || existing == nil || existing.Completed {
+		t.Fatalf("Reserve = %+v, %v, want the in-progress record", existing, err)
+	}
+
+	rec.Response = []byte("response")
+	if err := store.Complete(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	existing, err = store.Reserve(ctx, rec)
+	if err != nil || !existing.Completed || string(existing.Response) != "response" {
+		t.Fatalf("Reserve = %+v, %v, want the completed record", existing, err)
+	}
+
+	// an expired key is taken over and then cleaned up
+	expired := &Record{Key: "k2", Method: method, RequestHash: "h2", ExpiresAt: time.Now().Add(-time.Minute)}
+	if _, err := store.Reserve(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if existing, err := store.Reserve(ctx, expired); err != nil || existing != nil {
+		t.Fatalf("Reserve = %+v, %v, want the expired key taken over", existing, err)
+	}
+	if n, err := store.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Fatalf("DeleteExpired = %d, %v, want 1", n, err)
+	}
+}
@@ -0,0 +1,95 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Record is what is remembered about the first call made with a key.
+type Record struct {
+	Key         string
+	Method      string
+	RequestHash string
+	Completed   bool
+
+	Code         codes.Code
+	Message      string
+	ResponseType string
+	Response     []byte
+
+	// ExpiresAt ends the lease of an in-progress record, after which another
+	// call may take the key over, or the replay of a completed one.
+	ExpiresAt time.Time
+}
+
+// Store persists records. Implementations must make Reserve atomic so that
+// only one of several concurrent calls with the same key runs the handler.
+type Store interface {
+	// Reserve creates an in-progress record unless a live one exists for the
+	// key, in which case the existing record is returned instead.
+	Reserve(ctx context.Context, rec *Record) (existing *Record, err error)
+	// Complete stores the outcome of the call that reserved the key, along
+	// with its new ExpiresAt.
+	Complete(ctx context.Context, rec *Record) error
+	// Release forgets an in-progress reservation so the call can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore keeps records in process memory. It is meant for tests and
+// services without a database.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]*Record{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, rec *Record) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, r := range s.records {
+		if !r.ExpiresAt.After(now) {
+			delete(s.records, k)
+		}
+	}
+
+	if existing, ok := s.records[rec.Key]; ok {
+		cp := *existing
+		return &cp, nil
+	}
+
+	cp := *rec
+	s.records[rec.Key] = &cp
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *rec
+	cp.Completed = true
+	s.records[rec.Key] = &cp
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[key]; ok && !r.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
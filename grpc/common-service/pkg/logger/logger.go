@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"common-service/pkg/reqctx"
 	"io"
 	"log/slog"
 	"os"
@@ -8,7 +9,14 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-func InitLogger(level string) *slog.Logger {
+// level is shared by every logger InitLogger returns, so SetLevel applies
+// to all of them.
+var level = new(slog.LevelVar)
+
+// InitLogger sets up the default logger at level, one of debug, info, warn
+// or error. Empty or unknown levels log at info. Records go to stdout and
+// to logs/app.log.
+func InitLogger(lvl string) *slog.Logger {
 	w := &lumberjack.Logger{
 		Filename:   "logs/app.log",
 		MaxSize:    500,
@@ -17,13 +25,31 @@ func InitLogger(level string) *slog.Logger {
 		Compress:   true,
 	}
 
-	handler := slog.NewJSONHandler(io.MultiWriter(w, os.Stdout), &slog.HandlerOptions{
-		Level:     slog.LevelDebug,
+	return InitLoggerTo(lvl, io.MultiWriter(w, os.Stdout))
+}
+
+// InitLoggerTo is InitLogger writing to w, e.g. io.Discard in tests.
+func InitLoggerTo(lvl string, w io.Writer) *slog.Logger {
+	SetLevel(lvl)
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:     level,
 		AddSource: true,
 	})
 
-	logger := slog.New(handler)
+	// request-scoped values from baggage, on records logged with a context
+	logger := slog.New(reqctx.NewLogHandler(handler, reqctx.DefaultPolicy().SpanKeys))
 	slog.SetDefault(logger)
 
 	return logger
 }
+
+// SetLevel changes the level of the loggers InitLogger set up, e.g. after a
+// config reload.
+func SetLevel(lvl string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		l = slog.LevelInfo
+	}
+	level.Set(l)
+}
@@ -0,0 +1,178 @@
+package messaging
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+const (
+	memorySystem     = "memory"
+	memoryBufferSize = 64
+)
+
+// InMemoryBroker is a Publisher and Subscriber that delivers messages between
+// goroutines of the same process. It is meant for tests and local runs.
+type InMemoryBroker struct {
+	mu     sync.Mutex
+	subs   map[string][]*memorySubscription
+	next   map[string]int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewInMemoryBroker() *InMemoryBroker {
+	return &InMemoryBroker{
+		subs: map[string][]*memorySubscription{},
+		next: map[string]int{},
+	}
+}
+
+func (b *InMemoryBroker) Publish(ctx context.Context, msg *Message) (err error) {
+	ctx, span := startProducerSpan(ctx, memorySystem, msg)
+	defer func() { endSpan(span, err) }()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+
+	// every subscriber without a group gets a copy, groups get one per group
+	var targets []*memorySubscription
+	groups := map[string][]*memorySubscription{}
+	for _, sub := range b.subs[msg.Subject] {
+		if sub.group == "" {
+			targets = append(targets, sub)
+			continue
+		}
+		groups[sub.group] = append(groups[sub.group], sub)
+	}
+	for group, members := range groups {
+		key := msg.Subject + "\x00" + group
+		targets = append(targets, members[b.next[key]%len(members)])
+		b.next[key]++
+	}
+	b.mu.Unlock()
+
+	for _, sub := range targets {
+		delivered := &Message{
+			ID:      msg.ID,
+			Subject: msg.Subject,
+			Data:    msg.Data,
+			Headers: maps.Clone(msg.Headers),
+		}
+
+		select {
+		case sub.ch <- delivered:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (b *InMemoryBroker) Subscribe(ctx context.Context, subject, group string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &memorySubscription{
+		broker:  b,
+		subject: subject,
+		group:   group,
+		handler: handler,
+		ch:      make(chan *Message, memoryBufferSize),
+		done:    make(chan struct{}),
+	}
+	b.subs[subject] = append(b.subs[subject], sub)
+
+	b.wg.Add(1)
+	go sub.run(ctx)
+
+	return sub, nil
+}
+
+// Close stops all subscriptions and waits for queued messages to be handled.
+func (b *InMemoryBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	var subs []*memorySubscription
+	for _, s := range b.subs {
+		subs = append(subs, s...)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		_ = sub.Unsubscribe()
+	}
+	b.wg.Wait()
+	return nil
+}
+
+type memorySubscription struct {
+	broker  *InMemoryBroker
+	subject string
+	group   string
+	handler Handler
+	ch      chan *Message
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (s *memorySubscription) run(ctx context.Context) {
+	defer s.broker.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			// drain what was already accepted by Publish
+			for {
+				select {
+				case msg := <-s.ch:
+					s.handle(ctx, msg)
+				default:
+					return
+				}
+			}
+		case <-ctx.Done():
+			_ = s.Unsubscribe()
+			return
+		case msg := <-s.ch:
+			s.handle(ctx, msg)
+		}
+	}
+}
+
+func (s *memorySubscription) handle(ctx context.Context, msg *Message) {
+	msgCtx, span := startConsumerSpan(ctx, memorySystem, s.group, msg)
+	endSpan(span, s.handler(msgCtx, msg))
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.once.Do(func() {
+		close(s.done)
+
+		b := s.broker
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		subs := b.subs[s.subject]
+		for i, sub := range subs {
+			if sub == s {
+				b.subs[s.subject] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+	})
+	return nil
+}
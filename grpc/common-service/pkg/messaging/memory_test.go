@@ -0,0 +1,141 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupTracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	return recorder
+}
+
+func TestInMemoryBroker_PropagatesTraceAndBaggage(t *testing.T) {
+	recorder := setupTracing(t)
+
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	type received struct {
+		spanCtx trace.SpanContext
+		tenant  string
+	}
+	got := make(chan received, 1)
+
+	_, err := broker.Subscribe(context.Background(), "user.created", "", func(ctx context.Context, msg *Message) error {
+		got <- received{
+			spanCtx: trace.SpanContextFromContext(ctx),
+			tenant:  baggage.FromContext(ctx).Member("tenant.id").Value(),
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	member, _ := baggage.NewMember("tenant.id", "acme")
+	bag, _ := baggage.New(member)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+	ctx, root := otel.Tracer("test").Start(ctx, "root")
+
+	if err := broker.Publish(ctx, &Message{Subject: "user.created", Data: []byte(`{}`)}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	root.End()
+
+	var r received
+	select {
+	case r = <-got:
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+
+	if r.spanCtx.TraceID() != root.SpanContext().TraceID() {
+		t.Errorf("consumer trace id = %s, want %s", r.spanCtx.TraceID(), root.SpanContext().TraceID())
+	}
+	if r.tenant != "acme" {
+		t.Errorf("baggage tenant.id = %q, want %q", r.tenant, "acme")
+	}
+
+	_ = broker.Close()
+
+	var producer, consumer sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		switch s.SpanKind() {
+		case trace.SpanKindProducer:
+			producer = s
+		case trace.SpanKindConsumer:
+			consumer = s
+		}
+	}
+	if producer == nil || consumer == nil {
+		t.Fatalf("expected producer and consumer spans, got %d spans", len(recorder.Ended()))
+	}
+	if producer.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("producer parent = %s, want root %s", producer.Parent().SpanID(), root.SpanContext().SpanID())
+	}
+	if consumer.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("consumer parent = %s, want producer %s", consumer.Parent().SpanID(), producer.SpanContext().SpanID())
+	}
+}
+
+func TestInMemoryBroker_QueueGroupDeliversOnce(t *testing.T) {
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	delivered := make(chan string, 10)
+	for _, name := range []string{"a", "b"} {
+		_, err := broker.Subscribe(context.Background(), "orders", "workers", func(ctx context.Context, msg *Message) error {
+			delivered <- name
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := broker.Publish(context.Background(), &Message{Subject: "orders"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	_ = broker.Close()
+	close(delivered)
+
+	counts := map[string]int{}
+	for name := range delivered {
+		counts[name]++
+	}
+	if counts["a"]+counts["b"] != 4 || counts["a"] != 2 {
+		t.Errorf("deliveries = %v, want 2 per member", counts)
+	}
+}
+
+func TestInMemoryBroker_PublishAfterClose(t *testing.T) {
+	broker := NewInMemoryBroker()
+	_ = broker.Close()
+
+	if err := broker.Publish(context.Background(), &Message{Subject: "x"}); err != ErrClosed {
+		t.Errorf("Publish() error = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrClosed = errors.New("messaging: broker closed")
+
+// Message is a broker agnostic message. Headers carry the W3C trace context
+// and baggage alongside any application headers.
+type Message struct {
+	ID      string
+	Subject string
+	Data    []byte
+	Headers map[string]string
+}
+
+// Handler processes a message delivered to a subscription. The context carries
+// the trace context extracted from the message headers.
+type Handler func(ctx context.Context, msg *Message) error
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+type Subscriber interface {
+	// Subscribe registers handler for subject. Subscribers sharing the same
+	// queue group receive each message only once; an empty group delivers
+	// every message to every subscriber.
+	Subscribe(ctx context.Context, subject, group string, handler Handler) (Subscription, error)
+	Close() error
+}
+
+type Subscription interface {
+	Unsubscribe() error
+}
@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+const natsSystem = "nats"
+
+// NATSBroker publishes and subscribes through a NATS server, e.g. the one
+// started by docker-compose on nats://localhost:4222.
+type NATSBroker struct {
+	conn *nats.Conn
+}
+
+func NewNATSBroker(url string, opts ...nats.Option) (*NATSBroker, error) {
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	return &NATSBroker{conn: conn}, nil
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, msg *Message) (err error) {
+	_, span := startProducerSpan(ctx, natsSystem, msg)
+	defer func() { endSpan(span, err) }()
+
+	natsMsg := nats.NewMsg(msg.Subject)
+	natsMsg.Data = msg.Data
+	for k, v := range msg.Headers {
+		natsMsg.Header.Set(k, v)
+	}
+	if msg.ID != "" {
+		// lets JetStream streams de-duplicate redeliveries
+		natsMsg.Header.Set(nats.MsgIdHdr, msg.ID)
+	}
+
+	return b.conn.PublishMsg(natsMsg)
+}
+
+func (b *NATSBroker) Subscribe(ctx context.Context, subject, group string, handler Handler) (Subscription, error) {
+	cb := func(natsMsg *nats.Msg) {
+		msg := &Message{
+			ID:      natsMsg.Header.Get(nats.MsgIdHdr),
+			Subject: natsMsg.Subject,
+			Data:    natsMsg.Data,
+			Headers: make(map[string]string, len(natsMsg.Header)),
+		}
+		for k := range natsMsg.Header {
+			msg.Headers[k] = natsMsg.Header.Get(k)
+		}
+
+		msgCtx, span := startConsumerSpan(ctx, natsSystem, group, msg)
+		endSpan(span, handler(msgCtx, msg))
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if group != "" {
+		sub, err = b.conn.QueueSubscribe(subject, group, cb)
+	} else {
+		sub, err = b.conn.Subscribe(subject, cb)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", subject, err)
+	}
+
+	return sub, nil
+}
+
+// Close drains pending messages and closes the connection.
+func (b *NATSBroker) Close() error {
+	return b.conn.Drain()
+}
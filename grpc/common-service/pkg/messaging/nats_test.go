@@ -0,0 +1,117 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newNATSBroker connects a broker to an embedded NATS server on a free port.
+func newNATSBroker(t *testing.T) *NATSBroker {
+	t.Helper()
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	server := natsserver.RunServer(&opts)
+	t.Cleanup(server.Shutdown)
+
+	broker, err := NewNATSBroker(server.ClientURL())
+	if err != nil {
+		t.Fatalf("NewNATSBroker() error = %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+	return broker
+}
+
+func TestNATSBroker_PropagatesTraceAndBaggage(t *testing.T) {
+	recorder := setupTracing(t)
+	broker := newNATSBroker(t)
+
+	type received struct {
+		id      string
+		spanCtx trace.SpanContext
+		tenant  string
+	}
+	got := make(chan received, 1)
+
+	_, err := broker.Subscribe(context.Background(), "user.created", "workers", func(ctx context.Context, msg *Message) error {
+		got <- received{
+			id:      msg.ID,
+			spanCtx: trace.SpanContextFromContext(ctx),
+			tenant:  baggage.FromContext(ctx).Member("tenant.id").Value(),
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	// the server must know about the subscription before the publish
+	if err := broker.conn.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	member, _ := baggage.NewMember("tenant.id", "acme")
+	bag, _ := baggage.New(member)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+	ctx, root := otel.Tracer("test").Start(ctx, "root")
+
+	if err := broker.Publish(ctx, &Message{ID: "evt-1", Subject: "user.created", Data: []byte(`{}`)}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	root.End()
+
+	var r received
+	select {
+	case r = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+
+	if r.id != "evt-1" {
+		t.Errorf("message id = %q, want evt-1", r.id)
+	}
+	if r.spanCtx.TraceID() != root.SpanContext().TraceID() {
+		t.Errorf("consumer trace id = %s, want %s", r.spanCtx.TraceID(), root.SpanContext().TraceID())
+	}
+	if r.tenant != "acme" {
+		t.Errorf("baggage tenant.id = %q, want %q", r.tenant, "acme")
+	}
+
+	// the consumer span ends after the handler returns
+	if err := broker.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	var producer, consumer sdktrace.ReadOnlySpan
+	for consumer == nil && time.Now().Before(deadline) {
+		for _, s := range recorder.Ended() {
+			switch s.SpanKind() {
+			case trace.SpanKindProducer:
+				producer = s
+			case trace.SpanKindConsumer:
+				consumer = s
+			}
+		}
+		if consumer == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if producer == nil || consumer == nil {
+		t.Fatalf("expected producer and consumer spans, got %d spans", len(recorder.Ended()))
+	}
+	if producer.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("producer parent = %s, want root %s", producer.Parent().SpanID(), root.SpanContext().SpanID())
+	}
+	if consumer.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("consumer parent = %s, want producer %s", consumer.Parent().SpanID(), producer.SpanContext().SpanID())
+	}
+	if !consumer.Parent().IsRemote() {
+		t.Error("consumer parent was not extracted from the message headers")
+	}
+}
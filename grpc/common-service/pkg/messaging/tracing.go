@@ -0,0 +1,73 @@
+package messaging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "common-service/pkg/messaging"
+
+// startProducerSpan starts a producer span for msg and injects its context and
+// the current baggage into the message headers using the global propagator.
+func startProducerSpan(ctx context.Context, system string, msg *Message) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "send "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messageAttributes(system, msg)...),
+		trace.WithAttributes(
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingOperationName("send"),
+		),
+	)
+
+	if msg.Headers == nil {
+		msg.Headers = map[string]string{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(msg.Headers))
+
+	return ctx, span
+}
+
+// startConsumerSpan extracts the trace context and baggage from the message
+// headers and starts a consumer span as its child.
+func startConsumerSpan(ctx context.Context, system, group string, msg *Message) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+	attrs := append(messageAttributes(system, msg),
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingOperationName("process"),
+	)
+	if group != "" {
+		attrs = append(attrs, semconv.MessagingConsumerGroupName(group))
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, "process "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func messageAttributes(system string, msg *Message) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(system),
+		semconv.MessagingDestinationName(msg.Subject),
+		semconv.MessagingMessageBodySize(len(msg.Data)),
+	}
+	if msg.ID != "" {
+		attrs = append(attrs, semconv.MessagingMessageID(msg.ID))
+	}
+	return attrs
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,89 @@
+// Package metrics pushes OpenTelemetry metrics, such as the database pool
+// and gRPC client resilience instruments, to an OTLP/HTTP endpoint.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+const (
+	defaultEndpoint = "http://localhost:4318/v1/metrics"
+	defaultInterval = time.Minute
+)
+
+// Config is the metrics section of a service config.
+type Config struct {
+	// Endpoint is the full OTLP/HTTP metrics URL, e.g.
+	// http://prometheus:9090/api/v1/otlp/v1/metrics. Empty means
+	// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT, or http://localhost:4318/v1/metrics.
+	Endpoint string `mapstructure:"endpoint"`
+	// Interval is how often metrics are pushed, every minute by default.
+	Interval time.Duration `mapstructure:"interval" validate:"omitempty,min=1s"`
+}
+
+type Meter struct {
+	MeterProvider *sdkmetric.MeterProvider
+}
+
+// InitMeter creates a meter provider exporting to cfg.Endpoint and sets it
+// as the global meter provider.
+func InitMeter(ctx context.Context, cfg Config, serviceName string) (*Meter, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("metrics endpoint %q is not an http(s) URL", endpoint)
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if serviceName == "" {
+		serviceName = os.Getenv("OTEL_SERVICE_NAME")
+		if serviceName == "" {
+			serviceName = "unknown_service"
+		}
+	}
+
+	// an http URL turns TLS off
+	exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	res, _ := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String("1.0.0"),
+			semconv.DeploymentEnvironmentKey.String(os.Getenv("APP_ENV")),
+		),
+		resource.WithHost(),
+	)
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+
+	return &Meter{MeterProvider: mp}, nil
+}
+
+// Shutdown pushes the metrics not exported yet and stops the provider.
+func (m *Meter) Shutdown(ctx context.Context) error {
+	return m.MeterProvider.Shutdown(ctx)
+}
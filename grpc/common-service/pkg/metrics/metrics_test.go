@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitMeter_PushesToEndpoint(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/v1/otlp/v1/metrics" {
+			posts.Add(1)
+		}
+	}))
+	defer srv.Close()
+
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	ctx := context.Background()
+	m, err := InitMeter(ctx, Config{Endpoint: srv.URL + "/api/v1/otlp/v1/metrics"}, "test-service")
+	if err != nil {
+		t.Fatalf("InitMeter: %v", err)
+	}
+	if otel.GetMeterProvider() != m.MeterProvider {
+		t.Error("InitMeter did not install the global meter provider")
+	}
+
+	counter, err := otel.Meter("test").Int64Counter("test.calls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(ctx, 1)
+
+	// shutting down flushes what the interval has not pushed yet
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if posts.Load() == 0 {
+		t.Error("no metrics reached the endpoint")
+	}
+}
+
+func TestInitMeter_RejectsEndpointWithoutScheme(t *testing.T) {
+	if _, err := InitMeter(context.Background(), Config{Endpoint: "prometheus:9090"}, "test-service"); err == nil {
+		t.Error("expected an error for an endpoint that is not a URL")
+	}
+}
@@ -0,0 +1,75 @@
+// Package ping waits for a database, or any other dependency, that may
+// still be starting. It has no dependencies so every store can share it.
+package ping
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultTimeout        = 5 * time.Second
+	defaultBackoffInitial = 500 * time.Millisecond
+	defaultBackoffMax     = 5 * time.Second
+)
+
+// Config controls how long a client waits for the database at startup,
+// e.g. while a container is still starting.
+type Config struct {
+	// Attempts is the number of pings before giving up, at least one.
+	Attempts int `mapstructure:"attempts" validate:"omitempty,min=1"`
+	// Timeout bounds each ping, 5s by default.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// BackoffInitial is the wait after the first failure, doubled after
+	// each further one up to BackoffMax. Defaults are 500ms and 5s.
+	BackoffInitial time.Duration `mapstructure:"backoff_initial"`
+	BackoffMax     time.Duration `mapstructure:"backoff_max"`
+}
+
+func (c Config) withDefaults() Config {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.BackoffInitial <= 0 {
+		c.BackoffInitial = defaultBackoffInitial
+	}
+	if c.BackoffMax < c.BackoffInitial {
+		c.BackoffMax = max(defaultBackoffMax, c.BackoffInitial)
+	}
+	return c
+}
+
+// Retry calls ping, each call bounded by Timeout, until it succeeds or the
+// attempts run out, backing off between attempts. Zero values take the
+// defaults. It waits for any database that may still be starting.
+func (c Config) Retry(ctx context.Context, ping func(ctx context.Context) error) error {
+	cfg := c.withDefaults()
+
+	backoff := cfg.BackoffInitial
+	var err error
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err = ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.Attempts {
+			break
+		}
+
+		slog.WarnContext(ctx, "Database not ready, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, cfg.BackoffMax)
+	}
+	return fmt.Errorf("ping database (%d attempts): %w", cfg.Attempts, err)
+}
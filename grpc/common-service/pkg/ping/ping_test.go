@@ -0,0 +1,52 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Retry(t *testing.T) {
+	calls := 0
+	err := Config{Attempts: 3, BackoffInitial: time.Millisecond}.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Retry = %v after %d calls, want success on the third", err, calls)
+	}
+}
+
+func TestConfig_RetryGivesUp(t *testing.T) {
+	calls := 0
+	err := Config{Attempts: 2, BackoffInitial: time.Millisecond}.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if err == nil || !strings.Contains(err.Error(), "ping database (2 attempts): connection refused") || calls != 2 {
+		t.Fatalf("Retry = %v after %d calls", err, calls)
+	}
+}
+
+func TestConfig_RetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Config{Attempts: 100, BackoffInitial: time.Hour}.Retry(ctx, func(ctx context.Context) error {
+		cancel()
+		return errors.New("connection refused")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Retry = %v, want context.Canceled", err)
+	}
+}
+
+func TestConfig_WithDefaults(t *testing.T) {
+	cfg := Config{BackoffInitial: 10 * time.Second}.withDefaults()
+	if cfg.Attempts != 1 || cfg.Timeout != defaultTimeout || cfg.BackoffMax != 10*time.Second {
+		t.Errorf("withDefaults() = %+v", cfg)
+	}
+}
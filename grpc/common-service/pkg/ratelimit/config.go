@@ -0,0 +1,49 @@
+package ratelimit
+
+// Config is the rate_limit block of a service config. Rules are a list rather
+// than a map because viper treats the dots in method names as key separators.
+type Config struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Backend string `mapstructure:"backend"` // memory or redis
+	Redis   Redis  `mapstructure:"redis"`
+	Rules   []Rule `mapstructure:"rules"`
+	// TrustedHops is the number of proxies, such as a load balancer, between
+	// the client and the grpc-gateway. Peer keys skip the X-Forwarded-For
+	// entries they add.
+	TrustedHops int `mapstructure:"trusted_hops" validate:"min=0"`
+}
+
+type Redis struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+	Prefix   string `mapstructure:"prefix"`
+}
+
+// Rule limits one full gRPC method name, e.g. /auth.AuthService/Login, or
+// every method without rules of its own when Method is "*". A method can have
+// several rules with different keys, e.g. per username and per IP; a call
+// must pass all of them.
+type Rule struct {
+	Method            string  `mapstructure:"method"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
+	// Key selects what the bucket is keyed by: "peer", "principal" or
+	// "field:<name>" for a top level request field such as field:username.
+	Key string `mapstructure:"key"`
+}
+
+func (r Rule) limit() Limit {
+	burst := r.Burst
+	if burst <= 0 {
+		burst = max(1, int(r.RequestsPerSecond))
+	}
+	return Limit{Rate: r.RequestsPerSecond, Burst: burst}
+}
+
+// Validate checks the rules the way NewLimiter does, so that config loading
+// rejects a config with bad rules as a whole.
+func (c *Config) Validate() error {
+	_, err := NewLimiter(Config{Rules: c.Rules, TrustedHops: c.TrustedHops}, nil, nil)
+	return err
+}
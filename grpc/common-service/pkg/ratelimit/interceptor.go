@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfterHeader is set on rejected calls. The grpc-gateway forwards it as
+// the HTTP Retry-After header with GatewayHeaderMatcher.
+const RetryAfterHeader = "retry-after"
+
+type rule struct {
+	// bucket prefixes the keys of the rule's buckets
+	bucket string
+	limit  Limit
+	key    KeyFunc
+}
+
+type Limiter struct {
+	store       Store
+	trust       ForwardedTrust
+	trustedHops int
+	rules       atomic.Pointer[map[string][]rule]
+}
+
+// NewLimiter builds a limiter from cfg. Peer keys of calls trusted by trust
+// use the X-Forwarded-For metadata added by the grpc-gateway, skipping the
+// entries of cfg.TrustedHops proxies. A nil trust keys every call by its
+// transport peer.
+func NewLimiter(cfg Config, store Store, trust ForwardedTrust) (*Limiter, error) {
+	l := &Limiter{store: store, trust: trust, trustedHops: cfg.TrustedHops}
+	if err := l.SetRules(cfg.Rules); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetRules replaces the rules, e.g. after a config reload. Calls in flight
+// finish under the old rules. Buckets are kept, so a client does not get a
+// fresh burst when its limit changes. Invalid rules leave the current ones
+// in place.
+func (l *Limiter) SetRules(cfgRules []Rule) error {
+	rules := map[string][]rule{}
+	seen := map[Rule]bool{}
+	for _, r := range cfgRules {
+		if r.Method == "" {
+			return fmt.Errorf("ratelimit: rule without method")
+		}
+		if r.RequestsPerSecond <= 0 {
+			return fmt.Errorf("ratelimit: %s: requests_per_second must be positive", r.Method)
+		}
+
+		spec := r.Key
+		if spec == "" {
+			spec = "peer"
+		}
+		// rules sharing a key would share buckets
+		id := Rule{Method: r.Method, Key: spec}
+		if seen[id] {
+			return fmt.Errorf("ratelimit: %s: key %q used by two rules", r.Method, spec)
+		}
+		seen[id] = true
+
+		key, err := ParseKey(spec, l.trust, l.trustedHops)
+		if err != nil {
+			return fmt.Errorf("%w (method %s)", err, r.Method)
+		}
+		rules[r.Method] = append(rules[r.Method], rule{bucket: r.Method + "|" + spec, limit: r.limit(), key: key})
+	}
+
+	l.rules.Store(&rules)
+	return nil
+}
+
+func (l *Limiter) rulesFor(fullMethod string) []rule {
+	rules := *l.rules.Load()
+	if r, ok := rules[fullMethod]; ok {
+		return r
+	}
+	return rules["*"]
+}
+
+// UnaryServerInterceptor rejects calls over any of their method limits with
+// codes.ResourceExhausted and a retry-after header in seconds. Store errors
+// fail open so an unavailable backend does not take the service down.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var retryAfter time.Duration
+		limited := false
+		for _, r := range l.rulesFor(info.FullMethod) {
+			key, err := r.key(ctx, info.FullMethod, req)
+			if err != nil || key == "" {
+				continue
+			}
+
+			allowed, wait, err := l.store.Take(ctx, r.bucket+"|"+key, r.limit)
+			if err != nil {
+				slog.ErrorContext(ctx, "Rate limit store failed", "method", info.FullMethod, "error", err)
+				continue
+			}
+			if !allowed {
+				limited = true
+				retryAfter = max(retryAfter, wait)
+			}
+		}
+
+		if limited {
+			seconds := retryAfterSeconds(retryAfter)
+			_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %ds", seconds)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 1
+	}
+	return int(math.Min(math.Ceil(d.Seconds()), math.MaxInt32))
+}
+
+// GatewayHeaderMatcher is a runtime.WithOutgoingHeaderMatcher function that
+// exposes retry-after as the standard Retry-After header and keeps the
+// gateway's default Grpc-Metadata- prefix for everything else.
+func GatewayHeaderMatcher(key string) (string, bool) {
+	if key == RetryAfterHeader {
+		return "Retry-After", true
+	}
+	return fmt.Sprintf("Grpc-Metadata-%s", key), true
+}
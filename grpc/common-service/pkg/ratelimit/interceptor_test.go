@@ -0,0 +1,278 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(func() time.Time { return now })
+	limit := Limit{Rate: 2, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := store.Take(context.Background(), "k", limit); !ok {
+			t.Fatalf("take %d rejected within burst", i)
+		}
+	}
+
+	ok, retryAfter, _ := store.Take(context.Background(), "k", limit)
+	if ok || retryAfter != 500*time.Millisecond {
+		t.Fatalf("take over burst = %v, retry after %v; want rejected, 500ms", ok, retryAfter)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _, _ := store.Take(context.Background(), "k", limit); !ok {
+		t.Fatal("token was not refilled")
+	}
+	if ok, _, _ := store.Take(context.Background(), "other", limit); !ok {
+		t.Fatal("buckets are not isolated per key")
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(func() time.Time { return now })
+
+	limiter, err := NewLimiter(Config{Rules: []Rule{
+		{Method: "/auth.AuthService/Login", RequestsPerSecond: 1, Burst: 1, Key: "field:value"},
+		{Method: "*", RequestsPerSecond: 1, Burst: 1, Key: "peer"},
+	}}, store, trustAll)
+	if err != nil {
+		t.Fatalf("NewLimiter() error = %v", err)
+	}
+	intercept := limiter.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	call := func(method string, req any, forwardedFor string) (metadata.MD, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+		if forwardedFor != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwardedFor))
+		}
+		_, err := intercept(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return stream.header, err
+	}
+
+	login := "/auth.AuthService/Login"
+	if _, err := call(login, wrapperspb.String("Alice"), ""); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	header, err := call(login, wrapperspb.String("alice"), "")
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second login err = %v, want ResourceExhausted", err)
+	}
+	if got := header.Get(RetryAfterHeader); len(got) != 1 || got[0] != "1" {
+		t.Errorf("retry-after = %v, want [1]", got)
+	}
+
+	if _, err := call(login, wrapperspb.String("bob"), ""); err != nil {
+		t.Fatalf("other username was limited: %v", err)
+	}
+
+	// the gateway appends the address it received the request from, so only
+	// the last entry is known to be real
+	register := "/auth.AuthService/Register"
+	if _, err := call(register, nil, "203.0.113.7"); err != nil {
+		t.Fatalf("first register: %v", err)
+	}
+	if _, err := call(register, nil, "198.51.100.1, 203.0.113.7"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("spoofed forwarded ip err = %v, want ResourceExhausted", err)
+	}
+	if _, err := call(register, nil, "203.0.113.7, 198.51.100.1"); err != nil {
+		t.Fatalf("different forwarded ip was limited: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := call(login, wrapperspb.String("alice"), ""); err != nil {
+		t.Fatalf("login after refill: %v", err)
+	}
+}
+
+func TestPeerKey_TrustedHops(t *testing.T) {
+	key := PeerKey(trustAll, 1)
+	get := func(forwardedFor ...string) string {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+		if len(forwardedFor) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwardedFor[0]))
+		}
+		k, _ := key(ctx, "", nil)
+		return k
+	}
+
+	// spoofed, client (added by the load balancer), load balancer (added by
+	// the gateway)
+	if got := get("192.0.2.1, 203.0.113.7, 10.0.0.2"); got != "ip:203.0.113.7" {
+		t.Errorf("key = %q, want the entry before the trusted hop", got)
+	}
+	if got := get("10.0.0.2"); got != "ip:10.0.0.2" {
+		t.Errorf("key = %q, want the only entry", got)
+	}
+	if got := get(); got != "ip:127.0.0.1" {
+		t.Errorf("key = %q, want the transport peer", got)
+	}
+}
+
+func TestTrustLocalAddr(t *testing.T) {
+	gateway := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6000}
+	key := PeerKey(TrustLocalAddr(gateway), 0)
+	get := func(local net.Addr) string {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr:      &net.TCPAddr{IP: net.IPv4(192, 0, 2, 9), Port: 5000},
+			LocalAddr: local,
+		})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "203.0.113.7"))
+		k, _ := key(ctx, "", nil)
+		return k
+	}
+
+	if got := get(gateway); got != "ip:203.0.113.7" {
+		t.Errorf("gateway call key = %q, want the forwarded ip", got)
+	}
+	// a direct client cannot pick its own bucket
+	if got := get(&net.TCPAddr{IP: net.IPv4(0, 0, 0, 0), Port: 50052}); got != "ip:192.0.2.9" {
+		t.Errorf("direct call key = %q, want the transport peer", got)
+	}
+	if got := get(nil); got != "ip:192.0.2.9" {
+		t.Errorf("call without local address key = %q, want the transport peer", got)
+	}
+}
+
+func TestUnaryServerInterceptor_SeveralRulesPerMethod(t *testing.T) {
+	limiter, err := NewLimiter(Config{Rules: []Rule{
+		{Method: "/auth.AuthService/Login", RequestsPerSecond: 1, Burst: 2, Key: "field:value"},
+		{Method: "/auth.AuthService/Login", RequestsPerSecond: 1, Burst: 3, Key: "peer"},
+	}}, NewMemoryStore(), nil)
+	if err != nil {
+		t.Fatalf("NewLimiter() error = %v", err)
+	}
+	intercept := limiter.UnaryServerInterceptor()
+	call := func(username string) error {
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), &headerStream{})
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+		_, err := intercept(ctx, wrapperspb.String(username), &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"}, func(context.Context, any) (any, error) { return nil, nil })
+		return err
+	}
+
+	// one client trying a new username on every call hits the per-IP limit
+	for i, username := range []string{"a", "b", "c"} {
+		if err := call(username); err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+	}
+	if err := call("d"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("err = %v, want ResourceExhausted", err)
+	}
+
+	if _, err := NewLimiter(Config{Rules: []Rule{
+		{Method: "/x/Y", RequestsPerSecond: 1},
+		{Method: "/x/Y", RequestsPerSecond: 2, Key: "peer"},
+	}}, NewMemoryStore(), nil); err == nil {
+		t.Fatal("expected error for two rules with the same key")
+	}
+}
+
+func TestMemoryStore_DropsRefilledBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(func() time.Time { return now })
+	slow := Limit{Rate: 1.0 / 3600, Burst: 1}
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, _ = store.Take(context.Background(), key, Limit{Rate: 1, Burst: 5})
+	}
+	_, _, _ = store.Take(context.Background(), "slow", slow)
+
+	now = now.Add(sweepInterval)
+	_, _, _ = store.Take(context.Background(), "d", Limit{Rate: 1, Burst: 5})
+	if len(store.buckets) != 2 {
+		t.Fatalf("buckets = %d, want the refilled ones dropped", len(store.buckets))
+	}
+	if ok, _, _ := store.Take(context.Background(), "slow", slow); ok {
+		t.Fatal("bucket that had not refilled was dropped")
+	}
+}
+
+func TestNewLimiter_InvalidKey(t *testing.T) {
+	_, err := NewLimiter(Config{Rules: []Rule{{Method: "/x/Y", RequestsPerSecond: 1, Key: "cookie"}}}, NewMemoryStore(), nil)
+	if err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
+
+func TestLimiter_SetRules(t *testing.T) {
+	limiter, err := NewLimiter(Config{Rules: []Rule{{Method: "/x/Y", RequestsPerSecond: 1, Burst: 1, Key: "peer"}}}, NewMemoryStore(), nil)
+	if err != nil {
+		t.Fatalf("NewLimiter() error = %v", err)
+	}
+	intercept := limiter.UnaryServerInterceptor()
+	call := func() error {
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), &headerStream{})
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+		_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/x/Y"}, func(context.Context, any) (any, error) { return nil, nil })
+		return err
+	}
+
+	_ = call()
+	if err := call(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("err = %v, want ResourceExhausted", err)
+	}
+
+	if err := limiter.SetRules([]Rule{{Method: "/x/Y", RequestsPerSecond: 1, Key: "cookie"}}); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if err := call(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("err = %v, want the old rules kept", err)
+	}
+
+	if err := limiter.SetRules([]Rule{{Method: "/x/Other", RequestsPerSecond: 1, Key: "peer"}}); err != nil {
+		t.Fatalf("SetRules() error = %v", err)
+	}
+	if err := call(); err != nil {
+		t.Fatalf("err = %v, want /x/Y unlimited", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{Rules: []Rule{{Method: "/x/Y", RequestsPerSecond: 0, Key: "peer"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for a zero rate")
+	}
+	cfg.Rules[0].RequestsPerSecond = 1
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	if got, _ := GatewayHeaderMatcher("retry-after"); got != "Retry-After" {
+		t.Errorf("retry-after mapped to %q", got)
+	}
+	if got, _ := GatewayHeaderMatcher("x-custom"); got != "Grpc-Metadata-x-custom" {
+		t.Errorf("x-custom mapped to %q", got)
+	}
+}
+
+func trustAll(context.Context) bool { return true }
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// KeyFunc derives the bucket key of a call. An empty key skips limiting.
+type KeyFunc func(ctx context.Context, fullMethod string, req any) (string, error)
+
+type principalKey struct{}
+
+// ContextWithPrincipal stores the authenticated caller for PrincipalKey.
+func ContextWithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(principalKey{}).(string)
+	return p, ok && p != ""
+}
+
+// ForwardedTrust reports whether the X-Forwarded-For metadata of a call was
+// set by the grpc-gateway. Any other caller can send whatever it likes.
+type ForwardedTrust func(ctx context.Context) bool
+
+// TrustLocalAddr trusts calls that arrived on the listener at addr, such as
+// one only the in-process gateway dials.
+func TrustLocalAddr(addr net.Addr) ForwardedTrust {
+	want := addr.String()
+	return func(ctx context.Context) bool {
+		p, ok := peer.FromContext(ctx)
+		return ok && p.LocalAddr != nil && p.LocalAddr.String() == want
+	}
+}
+
+// PeerKey keys by client IP. For calls trusted by trust the client IP comes
+// from the X-Forwarded-For metadata added by the grpc-gateway, whose
+// transport peer is the gateway itself. Every proxy appends the address it
+// received the request from, so only entries counted from the right can be
+// trusted: trustedHops is the number of proxies in front of the gateway,
+// whose entries are skipped. Entries further left are set by the client.
+// Other calls, and every call when trust is nil, are keyed by their
+// transport peer.
+func PeerKey(trust ForwardedTrust, trustedHops int) KeyFunc {
+	return func(ctx context.Context, _ string, _ any) (string, error) {
+		if trust != nil && trust(ctx) {
+			if ip := forwardedFor(ctx, trustedHops); ip != "" {
+				return "ip:" + ip, nil
+			}
+		}
+
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return "", nil
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "ip:" + host, nil
+	}
+}
+
+// forwardedFor returns the X-Forwarded-For entry trustedHops from the right,
+// or the leftmost one when there are fewer entries.
+func forwardedFor(ctx context.Context, trustedHops int) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	var entries []string
+	for _, v := range md.Get("x-forwarded-for") {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				entries = append(entries, e)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[max(0, len(entries)-1-trustedHops)]
+}
+
+// PrincipalKey keys by authenticated principal and falls back to the peer
+// for anonymous calls.
+func PrincipalKey(fallback KeyFunc) KeyFunc {
+	return func(ctx context.Context, fullMethod string, req any) (string, error) {
+		if p, ok := PrincipalFromContext(ctx); ok {
+			return "principal:" + p, nil
+		}
+		return fallback(ctx, fullMethod, req)
+	}
+}
+
+// FieldKey keys by a top level string field of the request message, e.g. the
+// username of a LoginRequest. Requests without the field are keyed by
+// fallback.
+func FieldKey(name string, fallback KeyFunc) KeyFunc {
+	return func(ctx context.Context, fullMethod string, req any) (string, error) {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return fallback(ctx, fullMethod, req)
+		}
+
+		m := msg.ProtoReflect()
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.Kind() != protoreflect.StringKind {
+			return fallback(ctx, fullMethod, req)
+		}
+
+		value := strings.ToLower(strings.TrimSpace(m.Get(fd).String()))
+		if value == "" {
+			return fallback(ctx, fullMethod, req)
+		}
+		return "field:" + name + ":" + value, nil
+	}
+}
+
+// ParseKey maps the key setting of a Rule to a KeyFunc.
+func ParseKey(spec string, trust ForwardedTrust, trustedHops int) (KeyFunc, error) {
+	peerKey := PeerKey(trust, trustedHops)
+
+	switch {
+	case spec == "" || spec == "peer":
+		return peerKey, nil
+	case spec == "principal":
+		return PrincipalKey(peerKey), nil
+	case strings.HasPrefix(spec, "field:"):
+		name := strings.TrimPrefix(spec, "field:")
+		if name == "" {
+			return nil, fmt.Errorf("ratelimit: empty field name in key %q", spec)
+		}
+		return FieldKey(name, peerKey), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown key %q", spec)
+	}
+}
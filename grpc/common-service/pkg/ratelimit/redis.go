@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes from a bucket atomically on the server
+// so every replica sees the same limit.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+elseif rate > 0 then
+  retry = math.ceil((1 - tokens) / rate * 1000)
+else
+  retry = -1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+if rate > 0 then
+  redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+end
+
+return {allowed, retry}
+`)
+
+// RedisStore shares buckets between replicas through any Redis protocol
+// compatible server.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	res, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Rate, limit.Burst, time.Now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("rate limit store: %w", err)
+	}
+
+	if res[0] == 1 {
+		return true, 0, nil
+	}
+	if res[1] < 0 {
+		return false, time.Duration(math.MaxInt64), nil
+	}
+	return false, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// NewStore returns the store selected by cfg.Backend.
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return NewRedisStore(client, cfg.Redis.Prefix), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store keeps token buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	// Take removes one token from the bucket of key. When the bucket is empty
+	// it returns false and how long until a token is available.
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// sweepInterval is how often MemoryStore drops idle buckets.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is refilled to its burst. From then on it is
+	// no different from a new bucket and can be dropped.
+	full time.Time
+}
+
+// MemoryStore keeps buckets in process memory. It is the default store and the
+// one used in tests; replicas do not share limits. Buckets that have refilled
+// are dropped every sweepInterval, so keys chosen by clients, such as
+// usernames, do not accumulate.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// NewMemoryStoreWithClock is NewMemoryStore with an injectable time source.
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	s := NewMemoryStore()
+	s.now = now
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.full = fullAt(now, float64(limit.Burst)-b.tokens, limit.Rate)
+		return true, 0, nil
+	}
+	b.full = fullAt(now, float64(limit.Burst)-b.tokens, limit.Rate)
+
+	if limit.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64), nil
+	}
+	wait := (1 - b.tokens) / limit.Rate
+	return false, time.Duration(wait * float64(time.Second)), nil
+}
+
+// sweep drops the buckets that have refilled.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// fullAt is when rate has added missing tokens to a bucket. A bucket that
+// is never refilled is kept for a year.
+func fullAt(now time.Time, missing, rate float64) time.Time {
+	if rate <= 0 {
+		return now.Add(365 * 24 * time.Hour)
+	}
+	return now.Add(time.Duration(missing / rate * float64(time.Second)))
+}
@@ -0,0 +1,23 @@
+package reqctx
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor applies p to the baggage otelgrpc extracted from
+// the call, adds a request id when the caller sent none and copies the
+// selected keys onto the server span. Install it with the otelgrpc server
+// handler so the cleaned baggage is what downstream calls propagate.
+func UnaryServerInterceptor(p Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = p.Sanitize(ctx)
+		ctx, _ = ensureRequestID(ctx)
+		trace.SpanFromContext(ctx).SetAttributes(attributes(baggage.FromContext(ctx), p.SpanKeys)...)
+
+		return handler(ctx, req)
+	}
+}
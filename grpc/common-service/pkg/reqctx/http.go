@@ -0,0 +1,59 @@
+package reqctx
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// Headers a client may use instead of baggage. Baggage wins when both are
+// set.
+const (
+	HeaderRequestID     = "X-Request-Id"
+	HeaderTenantID      = "X-Tenant-Id"
+	HeaderClientVersion = "X-Client-Version"
+)
+
+var headerKeys = map[string]Key{
+	HeaderRequestID:     RequestID,
+	HeaderTenantID:      TenantID,
+	HeaderClientVersion: ClientVersion,
+}
+
+// Middleware reads the baggage header and the X-Request-Id, X-Tenant-Id and
+// X-Client-Version headers into the request context, subject to p. The
+// request id, generated if missing, is echoed in the X-Request-Id response
+// header.
+func Middleware(p Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.Baggage{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		for header, key := range headerKeys {
+			value := r.Header.Get(header)
+			if value == "" || Value(ctx, key) != "" {
+				continue
+			}
+			if withKey, err := With(ctx, key, value); err == nil {
+				ctx = withKey
+			}
+		}
+		ctx = p.Sanitize(ctx)
+
+		ctx, id := ensureRequestID(ctx)
+		w.Header().Set(HeaderRequestID, id)
+		trace.SpanFromContext(ctx).SetAttributes(attributes(baggage.FromContext(ctx), p.SpanKeys)...)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GatewayMetadata is a runtime.WithMetadata function that forwards the
+// baggage Middleware put in the request context to the gRPC call.
+func GatewayMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	carrier := propagation.MapCarrier{}
+	propagation.Baggage{}.Inject(ctx, carrier)
+	return metadata.New(carrier)
+}
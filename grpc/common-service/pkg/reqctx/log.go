@@ -0,0 +1,39 @@
+package reqctx
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+type logHandler struct {
+	slog.Handler
+	keys []Key
+}
+
+// NewLogHandler wraps h so records logged with a context carry the given
+// keys from its baggage as attributes.
+func NewLogHandler(h slog.Handler, keys []Key) slog.Handler {
+	return &logHandler{Handler: h, keys: keys}
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		b := baggage.FromContext(ctx)
+		for _, key := range h.keys {
+			if value := b.Member(string(key)).Value(); value != "" {
+				r.AddAttrs(slog.String(string(key), value))
+			}
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs), keys: h.keys}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name), keys: h.keys}
+}
@@ -0,0 +1,80 @@
+package reqctx
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const (
+	defaultMaxBytes   = 1024
+	defaultMaxMembers = 16
+)
+
+// Policy limits the baggage accepted from callers and selects the keys
+// copied onto spans and log records.
+type Policy struct {
+	// AllowedKeys lists the baggage keys accepted from callers. Members with
+	// other keys are dropped.
+	AllowedKeys []Key
+	// MaxBytes caps the encoded size of inbound baggage. Larger baggage is
+	// dropped as a whole.
+	MaxBytes int
+	// MaxMembers caps the number of members kept after filtering.
+	MaxMembers int
+	// SpanKeys lists the keys copied onto spans and log records.
+	SpanKeys []Key
+}
+
+// DefaultPolicy accepts CallerKeys, up to 1KiB, and copies every key this
+// package defines onto spans and log records.
+func DefaultPolicy() Policy {
+	return Policy{
+		AllowedKeys: CallerKeys,
+		MaxBytes:    defaultMaxBytes,
+		MaxMembers:  defaultMaxMembers,
+		SpanKeys:    Keys,
+	}
+}
+
+// Apply returns the members of b the policy accepts.
+func (p Policy) Apply(b baggage.Baggage) baggage.Baggage {
+	if b.Len() == 0 {
+		return b
+	}
+	if p.MaxBytes > 0 && len(b.String()) > p.MaxBytes {
+		slog.Debug("Dropping oversized baggage", "bytes", len(b.String()), "max", p.MaxBytes)
+		return baggage.Baggage{}
+	}
+
+	var members []baggage.Member
+	for _, key := range p.AllowedKeys {
+		m := b.Member(string(key))
+		if m.Key() == "" {
+			continue
+		}
+		if p.MaxMembers > 0 && len(members) == p.MaxMembers {
+			break
+		}
+		members = append(members, m)
+	}
+	if len(members) == b.Len() {
+		return b
+	}
+
+	filtered, err := baggage.New(members...)
+	if err != nil {
+		return baggage.Baggage{}
+	}
+	return filtered
+}
+
+// Sanitize replaces the baggage of ctx with what the policy accepts.
+func (p Policy) Sanitize(ctx context.Context) context.Context {
+	b := baggage.FromContext(ctx)
+	if b.Len() == 0 {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, p.Apply(b))
+}
@@ -0,0 +1,132 @@
+// Package reqctx carries request-scoped values such as the tenant and request
+// id through W3C baggage, so they reach every service a request touches:
+// the grpc-gateway maps them from HTTP headers, otelgrpc propagates them on
+// each gRPC hop, and they are copied onto spans and log records on the way.
+//
+// Inbound baggage is untrusted; Policy decides which keys are accepted and
+// how large it may be.
+package reqctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// Key is a baggage key of a request-scoped value.
+type Key string
+
+const (
+	TenantID      Key = "tenant.id"
+	RequestID     Key = "request.id"
+	UserID        Key = "user.id"
+	ClientVersion Key = "client.version"
+)
+
+// Keys lists the values this package knows about.
+var Keys = []Key{TenantID, RequestID, UserID, ClientVersion}
+
+// CallerKeys lists the keys callers may set. UserID names the authenticated
+// user, so it is not among them: only the code that verified the caller's
+// token sets it, with With, after the policy has cleaned the baggage.
+var CallerKeys = []Key{TenantID, RequestID, ClientVersion}
+
+// Values holds the request-scoped values of a context. Empty fields are
+// absent from the baggage.
+type Values struct {
+	TenantID      string
+	RequestID     string
+	UserID        string
+	ClientVersion string
+}
+
+func (v Values) get(key Key) string {
+	switch key {
+	case TenantID:
+		return v.TenantID
+	case RequestID:
+		return v.RequestID
+	case UserID:
+		return v.UserID
+	case ClientVersion:
+		return v.ClientVersion
+	}
+	return ""
+}
+
+// FromContext returns the values carried in the baggage of ctx.
+func FromContext(ctx context.Context) Values {
+	b := baggage.FromContext(ctx)
+	return Values{
+		TenantID:      b.Member(string(TenantID)).Value(),
+		RequestID:     b.Member(string(RequestID)).Value(),
+		UserID:        b.Member(string(UserID)).Value(),
+		ClientVersion: b.Member(string(ClientVersion)).Value(),
+	}
+}
+
+// NewContext returns a copy of ctx whose baggage carries the non-empty
+// fields of v. Other baggage members are kept.
+func NewContext(ctx context.Context, v Values) (context.Context, error) {
+	for _, key := range Keys {
+		value := v.get(key)
+		if value == "" {
+			continue
+		}
+		var err error
+		if ctx, err = With(ctx, key, value); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// With returns a copy of ctx whose baggage sets key to value.
+func With(ctx context.Context, key Key, value string) (context.Context, error) {
+	m, err := baggage.NewMemberRaw(string(key), value)
+	if err != nil {
+		return ctx, err
+	}
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx, err
+	}
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+// Value returns the value of key in the baggage of ctx, or "" if unset.
+func Value(ctx context.Context, key Key) string {
+	return baggage.FromContext(ctx).Member(string(key)).Value()
+}
+
+// ensureRequestID adds a random request id to ctx unless it already has one.
+func ensureRequestID(ctx context.Context) (context.Context, string) {
+	if id := Value(ctx, RequestID); id != "" {
+		return ctx, id
+	}
+	id := newRequestID()
+	if next, err := With(ctx, RequestID, id); err == nil {
+		ctx = next
+	}
+	return ctx, id
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// attributes returns keys found in b as span attributes.
+func attributes(b baggage.Baggage, keys []Key) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, key := range keys {
+		if value := b.Member(string(key)).Value(); value != "" {
+			attrs = append(attrs, attribute.String(string(key), value))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,264 @@
+package reqctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+func mustBaggage(t *testing.T, s string) baggage.Baggage {
+	t.Helper()
+	b, err := baggage.Parse(s)
+	if err != nil {
+		t.Fatalf("parse baggage %q: %v", s, err)
+	}
+	return b
+}
+
+func TestNewContext_RoundTrip(t *testing.T) {
+	ctx := baggage.ContextWithBaggage(context.Background(), mustBaggage(t, "other=1"))
+
+	want := Values{TenantID: "acme", RequestID: "req-1", UserID: "42", ClientVersion: "ios/3.2"}
+	ctx, err := NewContext(ctx, want)
+	if err != nil {
+		t.Fatalf("NewContext: %v", err)
+	}
+
+	if got := FromContext(ctx); got != want {
+		t.Errorf("FromContext = %+v, want %+v", got, want)
+	}
+	if got := baggage.FromContext(ctx).Member("other").Value(); got != "1" {
+		t.Errorf("other member = %q, want it kept", got)
+	}
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		in     string
+		want   map[string]string
+	}{
+		{
+			name:   "keeps allowed keys",
+			policy: DefaultPolicy(),
+			in:     "tenant.id=acme,request.id=r1",
+			want:   map[string]string{"tenant.id": "acme", "request.id": "r1"},
+		},
+		{
+			name:   "drops unknown keys",
+			policy: DefaultPolicy(),
+			in:     "tenant.id=acme,session=secret",
+			want:   map[string]string{"tenant.id": "acme"},
+		},
+		{
+			name:   "drops the user id sent by callers",
+			policy: DefaultPolicy(),
+			in:     "tenant.id=acme,user.id=42",
+			want:   map[string]string{"tenant.id": "acme"},
+		},
+		{
+			name:   "drops oversized baggage",
+			policy: Policy{AllowedKeys: Keys, MaxBytes: 32},
+			in:     "tenant.id=" + strings.Repeat("a", 40),
+			want:   map[string]string{},
+		},
+		{
+			name:   "caps members",
+			policy: Policy{AllowedKeys: Keys, MaxMembers: 1},
+			in:     "tenant.id=acme,request.id=r1",
+			want:   map[string]string{"tenant.id": "acme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Apply(mustBaggage(t, tt.in))
+
+			if got.Len() != len(tt.want) {
+				t.Errorf("members = %q, want %v", got.String(), tt.want)
+			}
+			for key, value := range tt.want {
+				if v := got.Member(key).Value(); v != value {
+					t.Errorf("%s = %q, want %q", key, v, value)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		wantTenant string
+		wantReqID  string
+	}{
+		{
+			name:       "headers",
+			header:     http.Header{"X-Tenant-Id": {"acme"}, "X-Request-Id": {"req-1"}},
+			wantTenant: "acme",
+			wantReqID:  "req-1",
+		},
+		{
+			name:       "baggage wins over headers",
+			header:     http.Header{"Baggage": {"tenant.id=from-baggage"}, "X-Tenant-Id": {"from-header"}},
+			wantTenant: "from-baggage",
+		},
+		{
+			name:   "disallowed baggage is dropped",
+			header: http.Header{"Baggage": {"session=secret"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got context.Context
+			h := Middleware(DefaultPolicy(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Context()
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+			req.Header = tt.header
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if v := Value(got, TenantID); v != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", v, tt.wantTenant)
+			}
+			if v := baggage.FromContext(got).Member("session").Value(); v != "" {
+				t.Errorf("session = %q, want dropped", v)
+			}
+
+			reqID := Value(got, RequestID)
+			if reqID == "" {
+				t.Fatal("no request id in context")
+			}
+			if tt.wantReqID != "" && reqID != tt.wantReqID {
+				t.Errorf("request id = %q, want %q", reqID, tt.wantReqID)
+			}
+			if h := rr.Header().Get(HeaderRequestID); h != reqID {
+				t.Errorf("%s header = %q, want %q", HeaderRequestID, h, reqID)
+			}
+		})
+	}
+}
+
+func TestGatewayMetadata(t *testing.T) {
+	ctx, err := NewContext(context.Background(), Values{TenantID: "acme", RequestID: "req-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := GatewayMetadata(ctx, nil)
+
+	carrier := propagation.MapCarrier{}
+	for key, values := range md {
+		carrier[key] = values[0]
+	}
+	got := FromContext(propagation.Baggage{}.Extract(context.Background(), carrier))
+	if got.TenantID != "acme" || got.RequestID != "req-1" {
+		t.Errorf("forwarded values = %+v", got)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx := baggage.ContextWithBaggage(context.Background(), mustBaggage(t, "tenant.id=acme,session=secret"))
+	ctx, span := tp.Tracer("test").Start(ctx, "user.UserService/CreateUser")
+
+	var handled context.Context
+	_, err := UnaryServerInterceptor(DefaultPolicy())(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"},
+		func(ctx context.Context, req any) (any, error) {
+			handled = ctx
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	if v := Value(handled, TenantID); v != "acme" {
+		t.Errorf("tenant = %q, want acme", v)
+	}
+	if v := baggage.FromContext(handled).Member("session").Value(); v != "" {
+		t.Errorf("session = %q, want dropped", v)
+	}
+	reqID := Value(handled, RequestID)
+	if reqID == "" {
+		t.Fatal("no request id generated")
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["tenant.id"] != "acme" || attrs["request.id"] != reqID {
+		t.Errorf("span attributes = %v", attrs)
+	}
+	if _, ok := attrs["session"]; ok {
+		t.Error("session copied onto span")
+	}
+}
+
+func TestSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(NewSpanProcessor(DefaultPolicy())),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	ctx, err := NewContext(context.Background(), Values{TenantID: "acme", UserID: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := tp.Tracer("test").Start(ctx, "UserRepository.CreateUser")
+	span.End()
+
+	attrs := map[string]string{}
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["tenant.id"] != "acme" {
+		t.Errorf("span attributes = %v", attrs)
+	}
+	// the processor cannot tell whether the caller or the service set it
+	if _, ok := attrs["user.id"]; ok {
+		t.Error("user.id copied onto span")
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil), []Key{TenantID, RequestID}))
+
+	ctx, err := NewContext(context.Background(), Values{TenantID: "acme", RequestID: "req-1", UserID: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.With("component", "test").InfoContext(ctx, "user created")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if record["tenant.id"] != "acme" || record["request.id"] != "req-1" || record["component"] != "test" {
+		t.Errorf("record = %v", record)
+	}
+	if _, ok := record["user.id"]; ok {
+		t.Error("user.id logged though not selected")
+	}
+}
@@ -0,0 +1,34 @@
+package reqctx
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type spanProcessor struct {
+	policy Policy
+}
+
+// NewSpanProcessor returns a span processor that copies the policy's
+// SpanKeys from the baggage of the parent context onto every span started.
+// Server spans start from the baggage the caller sent, so keys the policy
+// does not accept, such as UserID, are never copied.
+func NewSpanProcessor(p Policy) sdktrace.SpanProcessor {
+	return &spanProcessor{policy: p}
+}
+
+func (p *spanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	b := baggage.FromContext(parent)
+	if b.Len() == 0 {
+		return
+	}
+	s.SetAttributes(attributes(p.policy.Apply(b), p.policy.SpanKeys)...)
+}
+
+func (p *spanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (p *spanProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *spanProcessor) ForceFlush(context.Context) error { return nil }
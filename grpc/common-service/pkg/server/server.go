@@ -0,0 +1,76 @@
+// Package server binds the gRPC and HTTP listeners every service runs.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Config is where a server listens. Port 0 listens on a port the system
+// picks, see Listeners.GRPCAddr.
+type Config struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port" validate:"min=0,max=65535"`
+}
+
+// Address is the host:port the server listens on. An empty host listens on
+// every interface.
+func (c Config) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Listeners holds the gRPC and HTTP listeners of a service. Apps embed it
+// for GRPCAddr and HTTPAddr.
+type Listeners struct {
+	GRPC net.Listener
+	HTTP net.Listener
+}
+
+// Listen binds the configured gRPC and HTTP addresses, except where l
+// already holds a listener, such as one passed in through the app options.
+func Listen(l Listeners, grpcCfg, httpCfg Config) (Listeners, error) {
+	var err error
+	given := l
+	if l.GRPC == nil {
+		l.GRPC, err = net.Listen("tcp", grpcCfg.Address())
+		if err != nil {
+			return Listeners{}, fmt.Errorf("listen gRPC on %s: %w", grpcCfg.Address(), err)
+		}
+	}
+	if l.HTTP == nil {
+		l.HTTP, err = net.Listen("tcp", httpCfg.Address())
+		if err != nil {
+			if given.GRPC == nil {
+				l.GRPC.Close()
+			}
+			return Listeners{}, fmt.Errorf("listen HTTP on %s: %w", httpCfg.Address(), err)
+		}
+	}
+	return l, nil
+}
+
+// GRPCAddr is the address the gRPC server listens on, which tells the port
+// picked for a configured port of 0.
+func (l Listeners) GRPCAddr() string {
+	return l.GRPC.Addr().String()
+}
+
+// HTTPAddr is the address the HTTP server listens on.
+func (l Listeners) HTTPAddr() string {
+	return l.HTTP.Addr().String()
+}
+
+// CloseHTTP shuts srv down, which closes the HTTP listener. A nil srv means
+// the app never served, so the servers did not take over the listeners and
+// both are closed here.
+func (l Listeners) CloseHTTP(ctx context.Context, srv *http.Server) error {
+	if srv != nil {
+		return srv.Shutdown(ctx)
+	}
+	l.GRPC.Close()
+	l.HTTP.Close()
+	return nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"common-service/pkg/config"
+)
+
+func TestConfig_Address(t *testing.T) {
+	if got := (Config{Port: 8080}).Address(); got != ":8080" {
+		t.Errorf("Address() = %q, want :8080", got)
+	}
+	if got := (Config{Host: "::1", Port: 8080}).Address(); got != "[::1]:8080" {
+		t.Errorf("Address() = %q, want [::1]:8080", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, port := range []int{0, 8080, 65535} {
+		if err := config.Validate(&Config{Port: port}); err != nil {
+			t.Errorf("port %d: %v", port, err)
+		}
+	}
+	for _, port := range []int{-1, 65536} {
+		if err := config.Validate(&Config{Port: port}); err == nil {
+			t.Errorf("port %d: expected a validation error", port)
+		}
+	}
+}
+
+func TestListen_BindsConfiguredAddresses(t *testing.T) {
+	l, err := Listen(Listeners{}, Config{Host: "127.0.0.1"}, Config{Host: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.CloseHTTP(context.Background(), nil)
+
+	for _, addr := range []string{l.GRPCAddr(), l.HTTPAddr()} {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host != "127.0.0.1" || port == "0" {
+			t.Errorf("listening on %s, want 127.0.0.1 and a picked port", addr)
+		}
+	}
+	if l.GRPCAddr() == l.HTTPAddr() {
+		t.Errorf("gRPC and HTTP share %s", l.GRPCAddr())
+	}
+}
+
+func TestListen_KeepsGivenListeners(t *testing.T) {
+	given, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Listen(Listeners{HTTP: given}, Config{Host: "127.0.0.1"}, Config{Host: "256.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.CloseHTTP(context.Background(), nil)
+
+	if l.HTTP != given {
+		t.Error("HTTP listener was replaced")
+	}
+}
+
+func TestListen_AddressInUse(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	port := taken.Addr().(*net.TCPAddr).Port
+
+	given, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer given.Close()
+
+	_, err = Listen(Listeners{GRPC: given}, Config{}, Config{Host: "127.0.0.1", Port: port})
+	if err == nil || !strings.Contains(err.Error(), "listen HTTP on "+taken.Addr().String()) {
+		t.Fatalf("err = %v, want the HTTP address in use", err)
+	}
+
+	// a given listener belongs to the caller and stays open
+	conn, err := net.Dial("tcp", given.Addr().String())
+	if err != nil {
+		t.Fatalf("given listener was closed: %v", err)
+	}
+	conn.Close()
+
+	_, err = Listen(Listeners{}, Config{Host: "127.0.0.1", Port: port}, Config{Host: "127.0.0.1"})
+	if err == nil || !strings.Contains(err.Error(), "listen gRPC on "+taken.Addr().String()) {
+		t.Fatalf("err = %v, want the gRPC address in use", err)
+	}
+}
+
+func TestListeners_CloseHTTP(t *testing.T) {
+	l, err := Listen(Listeners{}, Config{Host: "127.0.0.1"}, Config{Host: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l.HTTP) }()
+
+	if err := l.CloseHTTP(context.Background(), srv); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+	l.GRPC.Close()
+
+	// without a server both listeners are closed
+	l, err = Listen(Listeners{}, Config{Host: "127.0.0.1"}, Config{Host: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CloseHTTP(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, lis := range []net.Listener{l.GRPC, l.HTTP} {
+		if _, err := lis.Accept(); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Accept = %v, want the listener closed", err)
+		}
+	}
+}
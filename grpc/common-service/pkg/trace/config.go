@@ -0,0 +1,17 @@
+package trace
+
+// Config is the tracing section of a service config.
+type Config struct {
+	Endpoint string `mapstructure:"endpoint"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1. Unset
+	// records every trace.
+	SampleRatio *float64 `mapstructure:"sample_ratio" validate:"omitempty,min=0,max=1"`
+}
+
+// Ratio returns SampleRatio, or 1 when it is unset.
+func (c Config) Ratio() float64 {
+	if c.SampleRatio == nil {
+		return 1
+	}
+	return *c.SampleRatio
+}
@@ -0,0 +1,32 @@
+package trace
+
+import (
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ratioSampler is a parent based trace ID ratio sampler whose ratio can be
+// changed while the tracer provider is in use.
+type ratioSampler struct {
+	current atomic.Pointer[sdktrace.Sampler]
+}
+
+func newRatioSampler(ratio float64) *ratioSampler {
+	s := &ratioSampler{}
+	s.set(ratio)
+	return s
+}
+
+func (s *ratioSampler) set(ratio float64) {
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	s.current.Store(&sampler)
+}
+
+func (s *ratioSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return (*s.current.Load()).ShouldSample(p)
+}
+
+func (s *ratioSampler) Description() string {
+	return (*s.current.Load()).Description()
+}
@@ -0,0 +1,36 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRatioSampler_Set(t *testing.T) {
+	s := newRatioSampler(0)
+	params := sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       oteltrace.TraceID{0x01},
+		Name:          "op",
+	}
+
+	if got := s.ShouldSample(params).Decision; got != sdktrace.Drop {
+		t.Errorf("ratio 0: decision = %v, want Drop", got)
+	}
+	s.set(1)
+	if got := s.ShouldSample(params).Decision; got != sdktrace.RecordAndSample {
+		t.Errorf("ratio 1: decision = %v, want RecordAndSample", got)
+	}
+}
+
+func TestConfig_Ratio(t *testing.T) {
+	if got := (Config{}).Ratio(); got != 1 {
+		t.Errorf("unset Ratio() = %g, want 1", got)
+	}
+	zero := 0.0
+	if got := (Config{SampleRatio: &zero}).Ratio(); got != 0 {
+		t.Errorf("Ratio() = %g, want 0", got)
+	}
+}
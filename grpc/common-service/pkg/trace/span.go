@@ -2,14 +2,138 @@ package trace
 
 import (
 	"context"
+	"runtime"
+	"strings"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
+const defaultTracerName = "app"
+
+// tracerName is the instrumentation scope of spans started by StartSpan. It
+// is the service name once InitTracer has run.
+var tracerName atomic.Value
+
+type spanConfig struct {
+	kind  trace.SpanKind
+	attrs []attribute.KeyValue
+	links []trace.Link
+}
+
+// SpanOption configures a span started by StartSpan.
+type SpanOption func(*spanConfig)
+
+// WithKind sets the span kind. Spans are internal by default.
+func WithKind(kind trace.SpanKind) SpanOption {
+	return func(c *spanConfig) {
+		c.kind = kind
+	}
+}
+
+// WithAttributes adds attributes to the span.
+func WithAttributes(attrs ...attribute.KeyValue) SpanOption {
+	return func(c *spanConfig) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithLinks links the span to other spans, e.g. the producer of a message
+// handled outside the producer's trace.
+func WithLinks(links ...trace.Link) SpanOption {
+	return func(c *spanConfig) {
+		c.links = append(c.links, links...)
+	}
+}
+
 // StartSpan starts a span with given name.
 // Use this in all layers (controller, usecase, repo).
-func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
-	tr := otel.Tracer("app")
-	return tr.Start(ctx, name)
+// The span carries code.function and code.namespace attributes of the caller.
+func StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, trace.Span) {
+	cfg := spanConfig{kind: trace.SpanKindInternal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	attrs := append(callerAttributes(1), cfg.attrs...)
+
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(cfg.kind),
+		trace.WithAttributes(attrs...),
+		trace.WithLinks(cfg.links...),
+	)
+}
+
+// End records err on span and marks it failed when err is not nil, then ends
+// the span. Use it with a named error result:
+//
+//	ctx, span := trace.StartSpan(ctx, "UserUsecase.CreateUser")
+//	defer func() { trace.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracer returns the tracer used by StartSpan from the global provider.
+func tracer() trace.Tracer {
+	return otel.Tracer(TracerName())
+}
+
+// TracerName returns the instrumentation scope used by StartSpan.
+func TracerName() string {
+	if name, ok := tracerName.Load().(string); ok && name != "" {
+		return name
+	}
+	return defaultTracerName
+}
+
+// useResource names the tracer after the service.name of res.
+func useResource(res *resource.Resource) {
+	if res == nil {
+		return
+	}
+	if name, ok := res.Set().Value(semconv.ServiceNameKey); ok && name.AsString() != "" {
+		tracerName.Store(name.AsString())
+	}
+}
+
+// callerAttributes describes the function skip frames above the caller of
+// callerAttributes.
+func callerAttributes(skip int) []attribute.KeyValue {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return nil
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return nil
+	}
+
+	namespace, function := splitFuncName(fn.Name())
+	attrs := []attribute.KeyValue{semconv.CodeFunctionKey.String(function)}
+	if namespace != "" {
+		attrs = append(attrs, semconv.CodeNamespaceKey.String(namespace))
+	}
+	return attrs
+}
+
+// splitFuncName splits a runtime function name such as
+// "user-service/internal/usecase.(*userUsecase).CreateUser" into its
+// namespace "user-service/internal/usecase.(*userUsecase)" and function
+// "CreateUser".
+func splitFuncName(name string) (namespace, function string) {
+	pkgEnd := strings.LastIndex(name, "/") + 1
+	dot := strings.LastIndex(name[pkgEnd:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:pkgEnd+dot], name[pkgEnd+dot+1:]
 }
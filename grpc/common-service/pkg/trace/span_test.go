@@ -0,0 +1,144 @@
+package trace
+
+import (
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type widget struct{}
+
+func (widget) create(ctx context.Context) {
+	_, span := StartSpan(ctx, "Widget.Create")
+	span.End()
+}
+
+func TestStartSpan_Defaults(t *testing.T) {
+	rec := tracetest.Install(t)
+
+	_, span := StartSpan(context.Background(), "UserUsecase.CreateUser")
+	span.End()
+
+	got := rec.Span(t, "UserUsecase.CreateUser").
+		IsRoot().
+		HasKind(trace.SpanKindInternal).
+		HasStatus(codes.Unset).
+		HasAttribute("code.function", "TestStartSpan_Defaults").
+		HasAttribute("code.namespace", "common-service/pkg/trace").
+		Span()
+	if name := got.InstrumentationScope().Name; name != defaultTracerName {
+		t.Errorf("scope = %q, want %q", name, defaultTracerName)
+	}
+}
+
+func TestStartSpan_CallerMethod(t *testing.T) {
+	rec := tracetest.Install(t)
+
+	widget{}.create(context.Background())
+
+	rec.Span(t, "Widget.Create").
+		HasAttribute("code.function", "create").
+		HasAttribute("code.namespace", "common-service/pkg/trace.widget")
+}
+
+func TestStartSpan_Options(t *testing.T) {
+	rec := tracetest.Install(t)
+
+	_, producer := StartSpan(context.Background(), "OutboxRelay.Publish")
+	producer.End()
+	link := producer.SpanContext()
+
+	_, span := StartSpan(context.Background(), "UserEvents.Handle",
+		WithKind(trace.SpanKindConsumer),
+		WithAttributes(attribute.String("messaging.destination.name", "user"), attribute.Int("attempt", 2)),
+		WithLinks(trace.Link{SpanContext: link}),
+	)
+	span.End()
+
+	rec.Span(t, "UserEvents.Handle").
+		IsRoot().
+		HasKind(trace.SpanKindConsumer).
+		HasAttribute("messaging.destination.name", "user").
+		HasAttribute("attempt", 2).
+		HasAttribute("code.function", "TestStartSpan_Options").
+		HasLink(link)
+}
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{name: "success", status: codes.Unset},
+		{name: "failure", err: errors.New("insert failed"), status: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tracetest.Install(t)
+
+			_, span := StartSpan(context.Background(), "UserRepository.CreateUser")
+			End(span, tt.err)
+
+			got := rec.Span(t, "UserRepository.CreateUser").HasStatus(tt.status)
+			if tt.err != nil {
+				got.HasError().HasEvent("exception")
+				if desc := got.Span().Status().Description; desc != tt.err.Error() {
+					t.Errorf("status description = %q, want %q", desc, tt.err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestTracerName_FromResource(t *testing.T) {
+	t.Cleanup(func() { tracerName.Store("") })
+	rec := tracetest.Install(t)
+
+	useResource(resource.NewSchemaless(semconv.ServiceNameKey.String("user-service")))
+	if got := TracerName(); got != "user-service" {
+		t.Fatalf("TracerName() = %q, want %q", got, "user-service")
+	}
+
+	_, span := StartSpan(context.Background(), "UserUsecase.CreateUser")
+	span.End()
+
+	scope := rec.Span(t, "UserUsecase.CreateUser").Span().InstrumentationScope()
+	if scope.Name != "user-service" {
+		t.Errorf("scope = %q, want %q", scope.Name, "user-service")
+	}
+
+	useResource(resource.Empty())
+	if got := TracerName(); got != "user-service" {
+		t.Errorf("resource without service.name changed the tracer name to %q", got)
+	}
+}
+
+func TestSplitFuncName(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		function  string
+	}{
+		{"user-service/internal/usecase.(*userUsecase).CreateUser", "user-service/internal/usecase.(*userUsecase)", "CreateUser"},
+		{"common-service/pkg/trace.StartSpan", "common-service/pkg/trace", "StartSpan"},
+		{"main.main", "main", "main"},
+		{"github.com/acme/svc.v2/pkg.Handler.func1", "github.com/acme/svc.v2/pkg.Handler", "func1"},
+		{"noDot", "", "noDot"},
+	}
+
+	for _, tt := range tests {
+		namespace, function := splitFuncName(tt.name)
+		if namespace != tt.namespace || function != tt.function {
+			t.Errorf("splitFuncName(%q) = %q, %q; want %q, %q", tt.name, namespace, function, tt.namespace, tt.function)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package trace
 
 import (
+	"common-service/pkg/reqctx"
 	"context"
 	"fmt"
 	"os"
@@ -15,6 +16,8 @@ import (
 
 type Tracer struct {
 	TracerProvider *sdktrace.TracerProvider
+
+	sampler *ratioSampler
 }
 
 // InitTracer initializes the tracer provider and sets it as the global tracer provider.
@@ -52,10 +55,14 @@ func InitTracer(ctx context.Context, endpoint string, serviceName string) (*Trac
 		resource.WithProcess(),
 	)
 
+	useResource(res)
+
+	sampler := newRatioSampler(1)
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // make configurable
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(reqctx.NewSpanProcessor(reqctx.DefaultPolicy())),
 	)
 
 	otel.SetTracerProvider(tp)
@@ -64,7 +71,14 @@ func InitTracer(ctx context.Context, endpoint string, serviceName string) (*Trac
 		propagation.Baggage{},
 	))
 
-	return &Tracer{TracerProvider: tp}, nil
+	return &Tracer{TracerProvider: tp, sampler: sampler}, nil
+}
+
+// SetSampleRatio samples the given fraction of new traces, from 0 to 1.
+// Spans with a parent follow the parent's decision. It can be called at any
+// time, e.g. after a config reload.
+func (t *Tracer) SetSampleRatio(ratio float64) {
+	t.sampler.set(ratio)
 }
 
 // Shutdown shuts down the tracer provider.
@@ -0,0 +1,227 @@
+package tracetest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanAssertion checks one recorded span. Checks report failures on t and
+// return the assertion so they can be chained.
+type SpanAssertion struct {
+	t     testing.TB
+	span  sdktrace.ReadOnlySpan
+	spans []sdktrace.ReadOnlySpan
+}
+
+// Span returns the underlying span, nil when it was not found.
+func (a *SpanAssertion) Span() sdktrace.ReadOnlySpan {
+	return a.span
+}
+
+// HasChild checks for a direct child called name.
+func (a *SpanAssertion) HasChild(name string) *SpanAssertion {
+	a.t.Helper()
+	a.Child(name)
+	return a
+}
+
+// Child checks for a direct child called name and returns an assertion on it.
+func (a *SpanAssertion) Child(name string) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, s := range a.spans {
+		if s.Name() == name && a.isParentOf(s) {
+			return &SpanAssertion{t: a.t, span: s, spans: a.spans}
+		}
+	}
+
+	a.errorf("has no child %q", name)
+	return &SpanAssertion{t: a.t, spans: a.spans}
+}
+
+// HasNoChild checks that no direct child is called name.
+func (a *SpanAssertion) HasNoChild(name string) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, s := range a.spans {
+		if s.Name() == name && a.isParentOf(s) {
+			a.errorf("has unexpected child %q", name)
+		}
+	}
+	return a
+}
+
+// HasDescendant checks for a span called name anywhere below this one.
+func (a *SpanAssertion) HasDescendant(name string) *SpanAssertion {
+	a.t.Helper()
+	if a.span != nil && a.descendant(name, nil) == nil {
+		a.errorf("has no descendant %q", name)
+	}
+	return a
+}
+
+// HasClientSpan checks for a client span called name anywhere below this
+// one, such as the otelgrpc span of an outgoing RPC.
+func (a *SpanAssertion) HasClientSpan(name string) *SpanAssertion {
+	a.t.Helper()
+
+	kind := trace.SpanKindClient
+	if a.span != nil && a.descendant(name, &kind) == nil {
+		a.errorf("has no client span %q below it", name)
+	}
+	return a
+}
+
+// HasParent checks that the direct parent is called name.
+func (a *SpanAssertion) HasParent(name string) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, s := range a.spans {
+		if s.Name() == name && s.SpanContext().SpanID() == a.span.Parent().SpanID() {
+			return a
+		}
+	}
+
+	a.errorf("is not a child of %q", name)
+	return a
+}
+
+// IsRoot checks that the span has no parent.
+func (a *SpanAssertion) IsRoot() *SpanAssertion {
+	a.t.Helper()
+	if a.span != nil && a.span.Parent().IsValid() {
+		a.errorf("has a parent, want a root span")
+	}
+	return a
+}
+
+func (a *SpanAssertion) HasKind(kind trace.SpanKind) *SpanAssertion {
+	a.t.Helper()
+	if a.span != nil && a.span.SpanKind() != kind {
+		a.errorf("has kind %s, want %s", a.span.SpanKind(), kind)
+	}
+	return a
+}
+
+func (a *SpanAssertion) HasStatus(code codes.Code) *SpanAssertion {
+	a.t.Helper()
+	if a.span != nil && a.span.Status().Code != code {
+		a.errorf("has status %s (%q), want %s", a.span.Status().Code, a.span.Status().Description, code)
+	}
+	return a
+}
+
+// HasError checks that the span status is Error and an exception event was
+// recorded.
+func (a *SpanAssertion) HasError() *SpanAssertion {
+	a.t.Helper()
+	return a.HasStatus(codes.Error).HasEvent("exception")
+}
+
+// HasAttribute checks an attribute value. Go ints are compared as the int64
+// OpenTelemetry stores.
+func (a *SpanAssertion) HasAttribute(key string, value any) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	want := normalize(value)
+	for _, kv := range a.span.Attributes() {
+		if string(kv.Key) != key {
+			continue
+		}
+		if got := kv.Value.AsInterface(); !reflect.DeepEqual(got, want) {
+			a.errorf("has %s = %v, want %v", key, got, want)
+		}
+		return a
+	}
+
+	a.errorf("has no attribute %s", key)
+	return a
+}
+
+func (a *SpanAssertion) HasEvent(name string) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, e := range a.span.Events() {
+		if e.Name == name {
+			return a
+		}
+	}
+
+	a.errorf("has no event %q", name)
+	return a
+}
+
+// HasLink checks for a link to the given span context.
+func (a *SpanAssertion) HasLink(sc trace.SpanContext) *SpanAssertion {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, l := range a.span.Links() {
+		if l.SpanContext.TraceID() == sc.TraceID() && l.SpanContext.SpanID() == sc.SpanID() {
+			return a
+		}
+	}
+
+	a.errorf("has no link to %s", sc.SpanID())
+	return a
+}
+
+func (a *SpanAssertion) isParentOf(s sdktrace.ReadOnlySpan) bool {
+	return s.Parent().IsValid() && s.Parent().SpanID() == a.span.SpanContext().SpanID()
+}
+
+func (a *SpanAssertion) descendant(name string, kind *trace.SpanKind) sdktrace.ReadOnlySpan {
+	for _, s := range a.spans {
+		if !a.isParentOf(s) {
+			continue
+		}
+		if s.Name() == name && (kind == nil || s.SpanKind() == *kind) {
+			return s
+		}
+		child := &SpanAssertion{t: a.t, span: s, spans: a.spans}
+		if found := child.descendant(name, kind); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (a *SpanAssertion) errorf(format string, args ...any) {
+	a.t.Helper()
+	a.t.Errorf("span %q %s, recorded:\n%s", a.span.Name(), fmt.Sprintf(format, args...), Tree(a.spans))
+}
+
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case attribute.Value:
+		return v.AsInterface()
+	}
+	return v
+}
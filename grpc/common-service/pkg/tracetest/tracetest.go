@@ -0,0 +1,227 @@
+// Package tracetest records spans in memory for tests and offers fluent
+// assertions on the recorded span tree:
+//
+//	rec := tracetest.Install(t)
+//	// exercise the code under test
+//	rec.Span(t, "UserUsecase.CreateUser").
+//		HasChild("UserRepository.CreateUser").
+//		HasClientSpan("product.ProductService/GetProduct")
+package tracetest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recorder keeps every span ended through its tracer provider.
+type Recorder struct {
+	recorder *tracetest.SpanRecorder
+	provider *sdktrace.TracerProvider
+
+	mu     sync.Mutex
+	offset int
+}
+
+func NewRecorder() *Recorder {
+	recorder := tracetest.NewSpanRecorder()
+	return &Recorder{
+		recorder: recorder,
+		provider: sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			sdktrace.WithSpanProcessor(recorder),
+		),
+	}
+}
+
+// Install makes a new recorder the global tracer provider, with trace
+// context and baggage propagation, for the duration of the test. Tests using
+// it must not run in parallel with other tests that record spans.
+func Install(t testing.TB) *Recorder {
+	t.Helper()
+
+	r := NewRecorder()
+
+	prevProvider := otel.GetTracerProvider()
+	prevPropagator := otel.GetTextMapPropagator()
+
+	otel.SetTracerProvider(r.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	t.Cleanup(func() {
+		_ = r.provider.Shutdown(t.Context())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return r
+}
+
+func (r *Recorder) TracerProvider() trace.TracerProvider {
+	return r.provider
+}
+
+// Ended returns the spans ended since the recorder was created or last
+// reset, in the order they ended.
+func (r *Recorder) Ended() []sdktrace.ReadOnlySpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.recorder.Ended()[r.offset:]
+}
+
+// Reset forgets the spans recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.offset = len(r.recorder.Ended())
+}
+
+// Span finds the first ended span called name. A missing span is reported
+// on t and the returned assertion does nothing.
+func (r *Recorder) Span(t testing.TB, name string) *SpanAssertion {
+	t.Helper()
+	return r.find(t, name, nil)
+}
+
+// ServerSpan is Span restricted to server spans, to tell the two sides of an
+// RPC apart.
+func (r *Recorder) ServerSpan(t testing.TB, name string) *SpanAssertion {
+	t.Helper()
+
+	kind := trace.SpanKindServer
+	return r.find(t, name, &kind)
+}
+
+// ClientSpan is Span restricted to client spans.
+func (r *Recorder) ClientSpan(t testing.TB, name string) *SpanAssertion {
+	t.Helper()
+
+	kind := trace.SpanKindClient
+	return r.find(t, name, &kind)
+}
+
+func (r *Recorder) find(t testing.TB, name string, kind *trace.SpanKind) *SpanAssertion {
+	t.Helper()
+
+	spans := r.Ended()
+	for _, s := range spans {
+		if s.Name() == name && (kind == nil || s.SpanKind() == *kind) {
+			return &SpanAssertion{t: t, span: s, spans: spans}
+		}
+	}
+
+	if kind != nil {
+		t.Errorf("no %s span %q recorded, got:\n%s", *kind, name, Tree(spans))
+	} else {
+		t.Errorf("no span %q recorded, got:\n%s", name, Tree(spans))
+	}
+	return &SpanAssertion{t: t, spans: spans}
+}
+
+// NoSpan reports a recorded span called name as an error.
+func (r *Recorder) NoSpan(t testing.TB, name string) {
+	t.Helper()
+
+	spans := r.Ended()
+	for _, s := range spans {
+		if s.Name() == name {
+			t.Errorf("unexpected span %q recorded:\n%s", name, Tree(spans))
+			return
+		}
+	}
+}
+
+// SameTrace checks that spans with all the given names were recorded and
+// that they belong to one trace. With several spans of one name, any of them
+// may match.
+func (r *Recorder) SameTrace(t testing.TB, names ...string) {
+	t.Helper()
+
+	spans := r.Ended()
+	traces := make(map[string]map[trace.TraceID]bool)
+	for _, s := range spans {
+		if traces[s.Name()] == nil {
+			traces[s.Name()] = make(map[trace.TraceID]bool)
+		}
+		traces[s.Name()][s.SpanContext().TraceID()] = true
+	}
+
+	var candidates map[trace.TraceID]bool
+	for _, name := range names {
+		ids, ok := traces[name]
+		if !ok {
+			t.Errorf("no span %q recorded, got:\n%s", name, Tree(spans))
+			return
+		}
+		if candidates == nil {
+			candidates = ids
+			continue
+		}
+		for id := range candidates {
+			if !ids[id] {
+				delete(candidates, id)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		t.Errorf("spans %q are not part of one trace, got:\n%s", names, Tree(spans))
+	}
+}
+
+// Len reports the number of spans called name.
+func (r *Recorder) Len(name string) int {
+	n := 0
+	for _, s := range r.Ended() {
+		if s.Name() == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Tree renders spans as an indented tree, one "name [kind, status]" line per
+// span, for failure messages.
+func Tree(spans []sdktrace.ReadOnlySpan) string {
+	children := make(map[trace.SpanID][]sdktrace.ReadOnlySpan)
+	known := make(map[trace.SpanID]bool)
+	for _, s := range spans {
+		known[s.SpanContext().SpanID()] = true
+	}
+
+	var roots []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		parent := s.Parent().SpanID()
+		if s.Parent().IsValid() && known[parent] {
+			children[parent] = append(children[parent], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	var b strings.Builder
+	var walk func(s sdktrace.ReadOnlySpan, depth int)
+	walk = func(s sdktrace.ReadOnlySpan, depth int) {
+		fmt.Fprintf(&b, "%s%s [%s, %s]\n", strings.Repeat("  ", depth), s.Name(), s.SpanKind(), s.Status().Code)
+		for _, child := range children[s.SpanContext().SpanID()] {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 1)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package tracetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// recordingTB captures assertion failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions_PassOnMatchingTree(t *testing.T) {
+	rec := Install(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "UserUsecase.CreateUser")
+	_, child := otel.Tracer("test").Start(ctx, "UserRepository.CreateUser", trace.WithAttributes(attribute.Int("rows", 1)))
+	child.RecordError(errors.New("boom"))
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	parent.End()
+
+	rec.Span(t, "UserUsecase.CreateUser").
+		IsRoot().
+		HasKind(trace.SpanKindInternal).
+		HasChild("UserRepository.CreateUser").
+		HasNoChild("Other").
+		Child("UserRepository.CreateUser").
+		HasParent("UserUsecase.CreateUser").
+		HasAttribute("rows", 1).
+		HasError()
+	rec.NoSpan(t, "Other")
+}
+
+func TestAssertions_ReportMismatches(t *testing.T) {
+	rec := Install(t)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "A")
+	span.End()
+
+	tb := &recordingTB{TB: t}
+	rec.Span(tb, "A").HasChild("B").HasStatus(codes.Error).HasAttribute("missing", "x")
+	rec.Span(tb, "C").HasChild("D")
+	rec.NoSpan(tb, "A")
+
+	if len(tb.errors) != 5 {
+		t.Fatalf("failures = %d, want 5:\n%s", len(tb.errors), strings.Join(tb.errors, "\n"))
+	}
+	if !strings.Contains(tb.errors[0], "A [internal, Unset]") {
+		t.Errorf("failure does not render the span tree: %s", tb.errors[0])
+	}
+}
+
+func TestReset(t *testing.T) {
+	rec := Install(t)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "A")
+	span.End()
+	rec.Reset()
+
+	if n := len(rec.Ended()); n != 0 {
+		t.Errorf("spans after reset = %d, want 0", n)
+	}
+}
+
+func TestServe_RecordsClientAndServerSpans(t *testing.T) {
+	rec := Install(t)
+
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	})
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "caller")
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	span.End()
+
+	rec.Span(t, "caller").HasClientSpan("grpc.health.v1.Health/Check")
+	rec.ClientSpan(t, "grpc.health.v1.Health/Check").HasParent("caller").HasChild("grpc.health.v1.Health/Check")
+	rec.ServerSpan(t, "grpc.health.v1.Health/Check").HasParent("grpc.health.v1.Health/Check")
+}
+
+func TestSameTrace(t *testing.T) {
+	rec := Install(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "A")
+	_, child := otel.Tracer("test").Start(ctx, "B")
+	child.End()
+	parent.End()
+	_, other := otel.Tracer("test").Start(context.Background(), "C")
+	other.End()
+
+	rec.SameTrace(t, "A", "B")
+
+	tb := &recordingTB{TB: t}
+	rec.SameTrace(tb, "A", "C")
+	rec.SameTrace(tb, "A", "D")
+	if len(tb.errors) != 2 {
+		t.Errorf("failures = %d, want 2:\n%s", len(tb.errors), strings.Join(tb.errors, "\n"))
+	}
+}
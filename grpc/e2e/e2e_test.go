@@ -0,0 +1,252 @@
+// Package e2e boots the auth, user and product services in one process over
+// bufconn and checks the auth -> user -> product chain end to end, including
+// the distributed trace it produces.
+package e2e
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	authtest "auth-service/apptest"
+	authpb "auth-service/pb"
+	"common-service/pkg/reqctx"
+	"common-service/pkg/tracetest"
+	producttest "product-service/apptest"
+	usertest "user-service/apptest"
+	userpb "user-service/pb"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type stack struct {
+	rec  *tracetest.Recorder
+	auth *authtest.Service
+	// authConn and userConn are client connections to the auth and user
+	// services, instrumented like a real caller.
+	authConn *grpc.ClientConn
+	userConn *grpc.ClientConn
+}
+
+func startStack(t *testing.T) *stack {
+	t.Helper()
+
+	rec := tracetest.Install(t)
+	ctx := context.Background()
+
+	productLis, userLis, authLis := listen(t), listen(t), listen(t)
+
+	product, err := producttest.Start(ctx, producttest.Options{
+		Listener:       productLis,
+		TracerProvider: rec.TracerProvider(),
+	})
+	if err != nil {
+		t.Fatalf("start product service: %v", err)
+	}
+	t.Cleanup(func() { stop(t, product.Stop) })
+
+	user, err := usertest.Start(ctx, usertest.Options{
+		Listener:           userLis,
+		ProductTarget:      "passthrough:///product-service",
+		ProductDialOptions: []grpc.DialOption{dialer(productLis)},
+		TracerProvider:     rec.TracerProvider(),
+	})
+	if err != nil {
+		t.Fatalf("start user service: %v", err)
+	}
+	t.Cleanup(func() { stop(t, user.Stop) })
+
+	auth, err := authtest.Start(ctx, authtest.Options{
+		Listener:        authLis,
+		Target:          "passthrough:///auth-service",
+		DialOptions:     []grpc.DialOption{dialer(authLis), grpc.WithTransportCredentials(insecure.NewCredentials())},
+		UserTarget:      "passthrough:///user-service",
+		UserDialOptions: []grpc.DialOption{dialer(userLis)},
+		TracerProvider:  rec.TracerProvider(),
+	})
+	if err != nil {
+		t.Fatalf("start auth service: %v", err)
+	}
+	t.Cleanup(func() { stop(t, auth.Stop) })
+
+	authConn, err := grpc.NewClient("passthrough:///auth-service",
+		dialer(authLis),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		t.Fatalf("dial auth service: %v", err)
+	}
+	t.Cleanup(func() { _ = authConn.Close() })
+
+	userConn, err := grpc.NewClient("passthrough:///user-service",
+		dialer(userLis),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		t.Fatalf("dial user service: %v", err)
+	}
+	t.Cleanup(func() { _ = userConn.Close() })
+
+	return &stack{rec: rec, auth: auth, authConn: authConn, userConn: userConn}
+}
+
+func TestRegister_SpansAuthUserAndProduct(t *testing.T) {
+	s := startStack(t)
+
+	resp, err := authpb.NewAuthServiceClient(s.authConn).Register(context.Background(), &authpb.RegisterUserRequest{
+		Username: "e2e",
+		Password: "e2e",
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !resp.GetSuccess() {
+		t.Errorf("Register success = false: %s", resp.GetMessage())
+	}
+
+	// the outbox relay publishes the UserCreated event in the background
+	waitForSpan(t, s.rec, "OutboxRelay.Publish UserCreated")
+
+	s.rec.ServerSpan(t, "auth.AuthService/Register").
+		HasChild("AuthUsecase.Register")
+	s.rec.Span(t, "AuthUsecase.Register").
+		HasClientSpan("user.UserService/CreateUser")
+	s.rec.ServerSpan(t, "user.UserService/CreateUser").
+		HasParent("user.UserService/CreateUser").
+		HasChild("UserUsecase.CreateUser")
+	s.rec.Span(t, "UserUsecase.CreateUser").
+		HasChild("UserRepository.CreateUser").
+		HasClientSpan("product.ProductService/GetProduct")
+	s.rec.ServerSpan(t, "product.ProductService/GetProduct").
+		HasChild("ProductUsecase.CreateProduct")
+
+	s.rec.SameTrace(t,
+		"auth.AuthService/Register",
+		"user.UserService/CreateUser",
+		"UserRepository.CreateUser",
+		"product.ProductService/GetProduct",
+		"ProductUsecase.CreateProduct",
+		"OutboxRelay.Publish UserCreated",
+	)
+}
+
+func TestListUsers_ReturnsRegisteredUsers(t *testing.T) {
+	s := startStack(t)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := authpb.NewAuthServiceClient(s.authConn).Register(ctx, &authpb.RegisterUserRequest{Username: "e2e", Password: "e2e"}); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	resp, err := userpb.NewUserServiceClient(s.userConn).ListUsers(ctx, &userpb.ListUsersRequest{Limit: 1000})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if resp.GetLimit() != 100 {
+		t.Errorf("limit = %d, want the clamped 100", resp.GetLimit())
+	}
+	if len(resp.GetUsers()) != 2 {
+		t.Fatalf("users = %v, want the 2 registered", resp.GetUsers())
+	}
+
+	s.rec.ServerSpan(t, "user.UserService/ListUsers").
+		HasChild("UserUsecase.ListUsers")
+	s.rec.Span(t, "UserUsecase.ListUsers").
+		HasChild("UserRepository.ListUsers")
+}
+
+func TestLoginThroughGateway_SpansAuthAndUser(t *testing.T) {
+	s := startStack(t)
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+s.auth.HTTPAddr()+"/v1/auth/login",
+		strings.NewReader(`{"username":"e2e","password":"e2e"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /v1/auth/login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	s.rec.ServerSpan(t, "auth.AuthService/Login").
+		HasChild("AuthUsecase.Login")
+	s.rec.SameTrace(t,
+		"auth.AuthService/Login",
+		"AuthUsecase.Login",
+		"user.UserService/GetUserByEmail",
+	)
+}
+
+func TestGatewayBaggage_ReachesUserService(t *testing.T) {
+	s := startStack(t)
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+s.auth.HTTPAddr()+"/v1/auth/login",
+		strings.NewReader(`{"username":"e2e","password":"e2e"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(reqctx.HeaderTenantID, "acme")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /v1/auth/login: %v", err)
+	}
+	resp.Body.Close()
+	requestID := resp.Header.Get(reqctx.HeaderRequestID)
+	if requestID == "" {
+		t.Fatalf("no %s response header", reqctx.HeaderRequestID)
+	}
+
+	for _, name := range []string{"auth.AuthService/Login", "user.UserService/GetUserByEmail"} {
+		s.rec.ServerSpan(t, name).
+			HasAttribute(string(reqctx.TenantID), "acme").
+			HasAttribute(string(reqctx.RequestID), requestID)
+	}
+}
+
+func listen(t *testing.T) *bufconn.Listener {
+	lis := bufconn.Listen(1 << 20)
+	t.Cleanup(func() { _ = lis.Close() })
+	return lis
+}
+
+func dialer(lis *bufconn.Listener) grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+func stop(t *testing.T, fn func() error) {
+	if err := fn(); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
+
+func waitForSpan(t *testing.T, rec *tracetest.Recorder, name string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for rec.Len(name) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("span %q not recorded within 5s", name)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"loadgen/internal/loadgen"
+	"loadgen/internal/targets"
+
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/trace"
+
+	productpb "product-service/pb"
+	"user-service/pb"
+
+	"google.golang.org/grpc"
+)
+
+var availableTargets = []string{"svc-a", "auth-login", "auth-register", "user-grpc", "product-grpc"}
+
+func main() {
+	var (
+		targetList  = flag.String("targets", "svc-a", "comma separated targets with optional weights, e.g. svc-a:3,auth-login:1; one of "+fmt.Sprint(availableTargets))
+		model       = flag.String("model", "open", "load model: open (fixed arrival rate) or closed (fixed number of workers)")
+		rps         = flag.Float64("rps", 10, "requests per second at full load (open model)")
+		concurrency = flag.Int("concurrency", 10, "workers at full load (closed model) or in-flight limit (open model)")
+		duration    = flag.Duration("duration", time.Minute, "how long to generate load")
+		profile     = flag.String("profile", "constant", "ramp-up profile: constant, linear or step")
+		rampUp      = flag.Duration("ramp-up", 0, "time to reach full load for the linear and step profiles")
+		steps       = flag.Int("steps", 5, "number of steps for the step profile")
+		think       = flag.Duration("think", 0, "pause between requests of a closed-model worker")
+		timeout     = flag.Duration("timeout", 5*time.Second, "per request timeout")
+
+		svcAURL       = flag.String("svc-a-url", "http://localhost:8080", "base URL of svc-a")
+		authURL       = flag.String("auth-url", "http://localhost:8081", "base URL of the auth-service gateway")
+		loginUser     = flag.String("login-username", "loadgen", "username used by auth-login, registered before the run unless it exists")
+		loginPassword = flag.String("login-password", "loadgen", "password used by auth-login")
+		userAddr      = flag.String("user-addr", "localhost:50051", "user-service gRPC address")
+		productAddr   = flag.String("product-addr", "localhost:50053", "product-service gRPC address")
+
+		traceEndpoint = flag.String("trace-endpoint", "", "OTLP HTTP endpoint, defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// tracing
+	tp, err := trace.InitTracer(ctx, *traceEndpoint, "loadgen")
+	if err != nil {
+		log.Fatalf("failed to init tracer: %v", err)
+	}
+	defer func() {
+		_ = tp.Shutdown(context.Background())
+	}()
+
+	loadProfile, err := loadgen.ParseProfile(*profile, *rampUp, *steps)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	weights, err := loadgen.ParseWeights(*targetList)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpClient := targets.NewHTTPClient(*concurrency)
+	// one connection per address, shared by the targets calling it
+	conns := map[string]*grpc.ClientConn{}
+	dial := func(addr string) *grpc.ClientConn {
+		if conn, ok := conns[addr]; ok {
+			return conn
+		}
+		conn, err := grpcclient.NewClient(grpcclient.Config{Target: addr})
+		if err != nil {
+			log.Fatalf("failed to create grpc client: %v", err)
+		}
+		conns[addr] = conn
+		return conn
+	}
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mix := &loadgen.Mix{}
+	for _, name := range names {
+		var target loadgen.Target
+		switch name {
+		case "svc-a":
+			target = targets.Ping(httpClient, *svcAURL)
+		case "auth-login":
+			target = targets.Login(httpClient, *authURL, *loginUser, *loginPassword)
+		case "auth-register":
+			target = targets.Register(httpClient, *authURL)
+		case "user-grpc":
+			target = targets.GetUserByEmail(pb.NewUserServiceClient(dial(*userAddr)))
+		case "product-grpc":
+			target = targets.GetProduct(productpb.NewProductServiceClient(dial(*productAddr)))
+		default:
+			log.Fatalf("unknown target %q, want one of %v", name, availableTargets)
+		}
+		mix.Add(target, weights[name])
+	}
+
+	// auth-login needs its user to exist
+	if _, ok := weights["auth-login"]; ok {
+		registerCtx, cancel := context.WithTimeout(ctx, *timeout)
+		err := targets.RegisterUser(registerCtx, httpClient, *authURL, *loginUser, *loginPassword)
+		cancel()
+		if err != nil {
+			log.Fatalf("failed to register login user %s: %v", *loginUser, err)
+		}
+	}
+
+	cfg := loadgen.Config{
+		Model:       loadgen.Model(*model),
+		Duration:    *duration,
+		Profile:     loadProfile,
+		RPS:         *rps,
+		Concurrency: *concurrency,
+		Think:       *think,
+		Timeout:     *timeout,
+	}
+
+	log.Printf("running %s model against %v for %s", cfg.Model, names, cfg.Duration)
+
+	report, err := loadgen.Run(ctx, cfg, mix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report.Print(os.Stdout)
+}
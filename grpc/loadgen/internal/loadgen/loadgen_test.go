@@ -0,0 +1,135 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		elapsed time.Duration
+		want    float64
+	}{
+		{"constant", Constant(), 0, 1},
+		{"linear start", Linear(10 * time.Second), 0, 0},
+		{"linear half", Linear(10 * time.Second), 5 * time.Second, 0.5},
+		{"linear done", Linear(10 * time.Second), time.Minute, 1},
+		{"step first", Step(10*time.Second, 4), time.Second, 0.25},
+		{"step third", Step(10*time.Second, 4), 6 * time.Second, 0.75},
+		{"step done", Step(10*time.Second, 4), 10 * time.Second, 1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile(tt.elapsed); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ParseWeights("svc-a:3, auth-login")
+	if err != nil {
+		t.Fatalf("ParseWeights: %v", err)
+	}
+	if weights["svc-a"] != 3 || weights["auth-login"] != 1 {
+		t.Errorf("weights = %v", weights)
+	}
+
+	for _, bad := range []string{"", "svc-a:0", "svc-a:x"} {
+		if _, err := ParseWeights(bad); err == nil {
+			t.Errorf("ParseWeights(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestHistogramAndPercentiles(t *testing.T) {
+	latencies := []time.Duration{
+		500 * time.Microsecond, 3 * time.Millisecond, 3 * time.Millisecond, 40 * time.Millisecond, 10 * time.Second,
+	}
+
+	counts := Histogram(latencies)
+	if counts[0] != 1 || counts[2] != 2 || counts[5] != 1 || counts[len(counts)-1] != 1 {
+		t.Errorf("histogram = %v", counts)
+	}
+	if got := Percentile(latencies, 50); got != 3*time.Millisecond {
+		t.Errorf("p50 = %s, want 3ms", got)
+	}
+	if got := Percentile(latencies, 100); got != 10*time.Second {
+		t.Errorf("max = %s, want 10s", got)
+	}
+}
+
+func TestRun_OpenModelKeepsRate(t *testing.T) {
+	var calls atomic.Int32
+	mix := &Mix{}
+	mix.Add(TargetFunc("t", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}), 1)
+
+	report, err := Run(context.Background(), Config{Model: Open, RPS: 100, Concurrency: 10, Duration: 500 * time.Millisecond}, mix)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if n := calls.Load(); n < 35 || n > 55 {
+		t.Errorf("calls = %d, want about 50", n)
+	}
+	if got := len(report.stats["t"].Latencies); got != int(calls.Load()) {
+		t.Errorf("recorded = %d, want %d", got, calls.Load())
+	}
+}
+
+func TestRun_OpenModelDropsWhenSaturated(t *testing.T) {
+	mix := &Mix{}
+	mix.Add(TargetFunc("slow", func(ctx context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}), 1)
+
+	report, err := Run(context.Background(), Config{Model: Open, RPS: 100, Concurrency: 2, Duration: 200 * time.Millisecond}, mix)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.dropped == 0 {
+		t.Error("expected dropped arrivals")
+	}
+}
+
+func TestRun_ClosedModelBoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	mix := &Mix{}
+	mix.Add(TargetFunc("t", func(ctx context.Context) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("boom")
+	}), 1)
+
+	report, err := Run(context.Background(), Config{Model: Closed, Concurrency: 3, Duration: 100 * time.Millisecond}, mix)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if peak.Load() > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak.Load())
+	}
+
+	var out strings.Builder
+	report.Print(&out)
+	if !strings.Contains(out.String(), "error: boom") || !strings.Contains(out.String(), "p99") {
+		t.Errorf("summary misses errors or percentiles:\n%s", out.String())
+	}
+}
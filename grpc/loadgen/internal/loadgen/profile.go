@@ -0,0 +1,50 @@
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Profile returns the share of the configured load (0..1) to apply after
+// elapsed time.
+type Profile func(elapsed time.Duration) float64
+
+func Constant() Profile {
+	return func(time.Duration) float64 { return 1 }
+}
+
+// Linear ramps from zero to full load over rampUp.
+func Linear(rampUp time.Duration) Profile {
+	return func(elapsed time.Duration) float64 {
+		if rampUp <= 0 || elapsed >= rampUp {
+			return 1
+		}
+		return float64(elapsed) / float64(rampUp)
+	}
+}
+
+// Step climbs to full load in equal steps spread over rampUp, starting at
+// the first step.
+func Step(rampUp time.Duration, steps int) Profile {
+	return func(elapsed time.Duration) float64 {
+		if rampUp <= 0 || steps <= 1 || elapsed >= rampUp {
+			return 1
+		}
+		step := math.Floor(float64(elapsed)/float64(rampUp)*float64(steps)) + 1
+		return step / float64(steps)
+	}
+}
+
+func ParseProfile(name string, rampUp time.Duration, steps int) (Profile, error) {
+	switch name {
+	case "constant":
+		return Constant(), nil
+	case "linear":
+		return Linear(rampUp), nil
+	case "step":
+		return Step(rampUp, steps), nil
+	default:
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+}
@@ -0,0 +1,142 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report collects the outcome of every request per target.
+type Report struct {
+	mu      sync.Mutex
+	start   time.Time
+	end     time.Time
+	dropped int
+	stats   map[string]*Stats
+}
+
+type Stats struct {
+	Latencies []time.Duration
+	Errors    int
+	// ErrorSamples keeps the first distinct error messages.
+	ErrorSamples []string
+}
+
+const maxErrorSamples = 5
+
+func NewReport() *Report {
+	return &Report{start: time.Now(), stats: make(map[string]*Stats)}
+}
+
+func (r *Report) Record(target string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[target]
+	if !ok {
+		s = &Stats{}
+		r.stats[target] = s
+	}
+
+	s.Latencies = append(s.Latencies, latency)
+	if err != nil {
+		s.Errors++
+		if msg := err.Error(); len(s.ErrorSamples) < maxErrorSamples && !slices.Contains(s.ErrorSamples, msg) {
+			s.ErrorSamples = append(s.ErrorSamples, msg)
+		}
+	}
+}
+
+// Drop counts an open-loop arrival that was skipped because the in-flight
+// limit was reached.
+func (r *Report) Drop() {
+	r.mu.Lock()
+	r.dropped++
+	r.mu.Unlock()
+}
+
+func (r *Report) Finish() {
+	r.mu.Lock()
+	r.end = time.Now()
+	r.mu.Unlock()
+}
+
+// Percentile returns the p-th percentile (0..100) of sorted latencies.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return sorted[idx]
+}
+
+// Buckets are the upper bounds of the summary histogram.
+var Buckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second,
+}
+
+// Histogram counts latencies per bucket, the last count holds everything
+// above the largest bound.
+func Histogram(latencies []time.Duration) []int {
+	counts := make([]int, len(Buckets)+1)
+	for _, l := range latencies {
+		i := sort.Search(len(Buckets), func(i int) bool { return l <= Buckets[i] })
+		counts[i]++
+	}
+	return counts
+}
+
+func (r *Report) Print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := r.end.Sub(r.start)
+	if r.end.IsZero() {
+		elapsed = time.Since(r.start)
+	}
+
+	names := make([]string, 0, len(r.stats))
+	for name := range r.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "duration %s, dropped %d\n", elapsed.Round(time.Millisecond), r.dropped)
+
+	for _, name := range names {
+		s := r.stats[name]
+		sorted := slices.Clone(s.Latencies)
+		slices.Sort(sorted)
+
+		fmt.Fprintf(w, "\n%s\n", name)
+		fmt.Fprintf(w, "  requests %d, errors %d (%.1f%%), %.1f req/s\n",
+			len(sorted), s.Errors, 100*float64(s.Errors)/float64(len(sorted)), float64(len(sorted))/elapsed.Seconds())
+		fmt.Fprintf(w, "  p50 %s  p90 %s  p99 %s  max %s\n",
+			Percentile(sorted, 50), Percentile(sorted, 90), Percentile(sorted, 99), Percentile(sorted, 100))
+
+		counts := Histogram(sorted)
+		peak := slices.Max(counts)
+		for i, count := range counts {
+			if count == 0 {
+				continue
+			}
+			label := "> " + Buckets[len(Buckets)-1].String()
+			if i < len(Buckets) {
+				label = "<= " + Buckets[i].String()
+			}
+			bar := strings.Repeat("#", max(1, 40*count/peak))
+			fmt.Fprintf(w, "  %9s %7d %s\n", label, count, bar)
+		}
+
+		for _, msg := range s.ErrorSamples {
+			fmt.Fprintf(w, "  error: %s\n", msg)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type Model string
+
+const (
+	// Open sends requests at the configured rate whether or not earlier ones
+	// have finished, like independent users would.
+	Open Model = "open"
+	// Closed keeps a fixed number of workers that each wait for their
+	// response before sending the next request.
+	Closed Model = "closed"
+)
+
+type Config struct {
+	Model    Model
+	Duration time.Duration
+	Profile  Profile
+	// RPS is the open-loop arrival rate at full load.
+	RPS float64
+	// Concurrency is the number of closed-loop workers at full load, and the
+	// in-flight limit of the open loop.
+	Concurrency int
+	// Think is the pause of a closed-loop worker between requests.
+	Think time.Duration
+	// Timeout bounds each request.
+	Timeout time.Duration
+}
+
+func (c Config) Validate() error {
+	switch c.Model {
+	case Open:
+		if c.RPS <= 0 {
+			return fmt.Errorf("open model needs a positive rps")
+		}
+	case Closed:
+	default:
+		return fmt.Errorf("unknown model %q", c.Model)
+	}
+	if c.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive")
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	return nil
+}
+
+// Run drives mix until the duration elapses or ctx is cancelled, then waits
+// for in-flight requests and returns the report.
+func Run(ctx context.Context, cfg Config, mix *Mix) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if mix.Len() == 0 {
+		return nil, fmt.Errorf("no targets")
+	}
+	if cfg.Profile == nil {
+		cfg.Profile = Constant()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	report := NewReport()
+	if cfg.Model == Open {
+		runOpen(ctx, cfg, mix, report)
+	} else {
+		runClosed(ctx, cfg, mix, report)
+	}
+	report.Finish()
+
+	return report, nil
+}
+
+// minPause keeps the open loop from spinning while the profile is at zero.
+const minPause = 10 * time.Millisecond
+
+func runOpen(ctx context.Context, cfg Config, mix *Mix, report *Report) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	slots := make(chan struct{}, cfg.Concurrency)
+	start := time.Now()
+	next := start
+
+	for {
+		rate := cfg.RPS * cfg.Profile(time.Since(start))
+		if rate <= 0 {
+			next = next.Add(minPause)
+		} else {
+			next = next.Add(time.Duration(float64(time.Second) / rate))
+		}
+
+		if !sleepUntil(ctx, next) {
+			return
+		}
+		if rate <= 0 {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			report.Drop()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			do(ctx, cfg, mix.Pick(), report)
+		}()
+	}
+}
+
+func runClosed(ctx context.Context, cfg Config, mix *Mix, report *Report) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	start := time.Now()
+
+	for i := range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				active := int(math.Ceil(float64(cfg.Concurrency) * cfg.Profile(time.Since(start))))
+				if i >= active {
+					if !sleepUntil(ctx, time.Now().Add(minPause)) {
+						return
+					}
+					continue
+				}
+
+				do(ctx, cfg, mix.Pick(), report)
+
+				if cfg.Think > 0 && !sleepUntil(ctx, time.Now().Add(cfg.Think)) {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func do(ctx context.Context, cfg Config, target Target, report *Report) {
+	// the run deadline must not cut the last requests short, or they would
+	// show up as errors in the report
+	ctx = context.WithoutCancel(ctx)
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := target.Do(ctx)
+	report.Record(target.Name(), time.Since(start), err)
+}
+
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Target is one kind of request the generator can send. Do must be safe for
+// concurrent use.
+type Target interface {
+	Name() string
+	Do(ctx context.Context) error
+}
+
+type weighted struct {
+	target Target
+	weight int
+}
+
+// Mix picks targets at random in proportion to their weights.
+type Mix struct {
+	targets []weighted
+	total   int
+}
+
+func (m *Mix) Add(t Target, weight int) {
+	if weight <= 0 {
+		return
+	}
+	m.targets = append(m.targets, weighted{target: t, weight: weight})
+	m.total += weight
+}
+
+func (m *Mix) Len() int {
+	return len(m.targets)
+}
+
+func (m *Mix) Pick() Target {
+	n := rand.IntN(m.total)
+	for _, w := range m.targets {
+		if n < w.weight {
+			return w.target
+		}
+		n -= w.weight
+	}
+	return m.targets[len(m.targets)-1].target
+}
+
+// ParseWeights parses "svc-a:3,auth-login" into name -> weight. Entries
+// without a weight count as 1.
+func ParseWeights(s string) (map[string]int, error) {
+	weights := make(map[string]int)
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, weight, found := strings.Cut(part, ":")
+		w := 1
+		if found {
+			var err error
+			if w, err = strconv.Atoi(weight); err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid weight %q for target %s", weight, name)
+			}
+		}
+		weights[name] = w
+	}
+
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("no targets given")
+	}
+	return weights, nil
+}
+
+type targetFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// TargetFunc adapts a function to Target.
+func TargetFunc(name string, fn func(ctx context.Context) error) Target {
+	return &targetFunc{name: name, fn: fn}
+}
+
+func (t *targetFunc) Name() string                 { return t.name }
+func (t *targetFunc) Do(ctx context.Context) error { return t.fn(ctx) }
@@ -0,0 +1,31 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"loadgen/internal/loadgen"
+
+	productpb "product-service/pb"
+	"user-service/pb"
+)
+
+// GetUserByEmail calls UserService/GetUserByEmail directly with a random
+// address, so requests are not served from one hot row.
+func GetUserByEmail(client pb.UserServiceClient) loadgen.Target {
+	return loadgen.TargetFunc("user.UserService/GetUserByEmail", func(ctx context.Context) error {
+		email := fmt.Sprintf("loadgen-%d@example.com", rand.IntN(1000))
+		_, err := client.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: email})
+		return err
+	})
+}
+
+// GetProduct calls ProductService/GetProduct directly. The service has no
+// way to list products, so it asks for the one it seeds, id 1.
+func GetProduct(client productpb.ProductServiceClient) loadgen.Target {
+	return loadgen.TargetFunc("product.ProductService/GetProduct", func(ctx context.Context) error {
+		_, err := client.GetProduct(ctx, &productpb.GetProductRequest{Id: "1"})
+		return err
+	})
+}
@@ -0,0 +1,129 @@
+package targets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"loadgen/internal/loadgen"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHTTPClient returns a pooled client whose requests start their own
+// traces, so every request shows up as a root client span.
+func NewHTTPClient(maxConns int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxConns
+	transport.MaxIdleConnsPerHost = maxConns
+
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: otelhttp.NewTransport(transport,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return "loadgen " + r.Method + " " + r.URL.Path
+			}),
+		),
+	}
+}
+
+// Ping hits svc-a's /ping.
+func Ping(client *http.Client, baseURL string) loadgen.Target {
+	return loadgen.TargetFunc("svc-a /ping", func(ctx context.Context) error {
+		return send(ctx, client, http.MethodGet, baseURL+"/ping", nil, nil)
+	})
+}
+
+// Login posts the given credentials to the auth-service gateway.
+func Login(client *http.Client, baseURL, username, password string) loadgen.Target {
+	body := map[string]string{"username": username, "password": password}
+
+	return loadgen.TargetFunc("auth POST /v1/auth/login", func(ctx context.Context) error {
+		return send(ctx, client, http.MethodPost, baseURL+"/v1/auth/login", body, nil)
+	})
+}
+
+// Register signs up a fresh user per request through the auth-service
+// gateway, with an idempotency key like a real client would send.
+func Register(client *http.Client, baseURL string) loadgen.Target {
+	return loadgen.TargetFunc("auth POST /v1/auth/register", func(ctx context.Context) error {
+		id := randomHex(8)
+		body := map[string]string{"username": "loadgen-" + id, "password": "loadgen-" + id}
+		header := http.Header{"Idempotency-Key": []string{randomHex(16)}}
+
+		return send(ctx, client, http.MethodPost, baseURL+"/v1/auth/register", body, header)
+	})
+}
+
+// RegisterUser signs up username once through the auth-service gateway,
+// e.g. the user Login logs in as. A user that already exists is not an
+// error.
+func RegisterUser(ctx context.Context, client *http.Client, baseURL, username, password string) error {
+	body := map[string]string{"username": username, "password": password}
+	header := http.Header{"Idempotency-Key": []string{randomHex(16)}}
+
+	err := send(ctx, client, http.MethodPost, baseURL+"/v1/auth/register", body, header)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+// statusError is a response outside 2xx.
+type statusError struct {
+	method, path string
+	code         int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d", e.method, e.path, e.code)
+}
+
+func send(ctx context.Context, client *http.Client, method, url string, body any, header http.Header) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{method: method, path: req.URL.Path, code: resp.StatusCode}
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
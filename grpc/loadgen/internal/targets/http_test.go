@@ -0,0 +1,43 @@
+package targets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterUser(t *testing.T) {
+	registered := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if r.URL.Path != "/v1/auth/register" || r.Header.Get("Idempotency-Key") == "" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case body["username"] == "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case registered[body["username"]]:
+			w.WriteHeader(http.StatusConflict)
+		default:
+			registered[body["username"]] = true
+		}
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(1)
+	for i := range 2 {
+		if err := RegisterUser(context.Background(), client, srv.URL, "loadgen", "secret"); err != nil {
+			t.Fatalf("RegisterUser() call %d error = %v", i+1, err)
+		}
+	}
+	if !registered["loadgen"] {
+		t.Error("user was not registered")
+	}
+
+	if err := RegisterUser(context.Background(), client, srv.URL, "broken", "secret"); err == nil {
+		t.Error("RegisterUser() error = nil, want the 500 reported")
+	}
+}
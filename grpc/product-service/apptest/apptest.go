@@ -0,0 +1,119 @@
+// Package apptest runs the product service in process for integration
+// tests. Storage is in memory unless E2E_MONGODB_URI points at a MongoDB
+// server.
+package apptest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"product-service/internal/app"
+	"product-service/internal/config"
+	"product-service/internal/domain"
+	"product-service/internal/repository"
+	"product-service/migrations"
+
+	"common-service/pkg/db/mongodb"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// MongoDBURIEnv names the variable holding the URI of a real server.
+const MongoDBURIEnv = "E2E_MONGODB_URI"
+
+type Options struct {
+	// Listener serves gRPC, usually a bufconn listener.
+	Listener       net.Listener
+	TracerProvider oteltrace.TracerProvider
+	// MeterProvider records the service metrics, which are dropped when
+	// nil.
+	MeterProvider metric.MeterProvider
+	// LogOutput receives the service logs, which are discarded when nil.
+	LogOutput io.Writer
+}
+
+type Service struct {
+	app    *app.App
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// Start wires the service like cmd/server.go does and serves it until Stop.
+func Start(ctx context.Context, opts Options) (*Service, error) {
+	repo, err := storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	a, err := app.NewApp(ctx,
+		app.WithConfig(&config.Config{}),
+		app.WithTracerProvider(opts.TracerProvider),
+		app.WithMeterProvider(meterProvider(opts)),
+		app.WithLogOutput(logOutput(opts)),
+		app.WithGRPCListener(opts.Listener),
+		app.WithHTTPListener(httpListener),
+		app.WithProductRepository(repo),
+	)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &Service{app: a, cancel: cancel, done: make(chan error, 1)}
+	go func() { s.done <- a.Run() }()
+
+	return s, nil
+}
+
+// Stop cancels the service, waits for Run to return and shuts it down.
+func (s *Service) Stop() error {
+	s.cancel()
+	if err := <-s.done; err != nil {
+		return err
+	}
+	return s.app.Shutdown()
+}
+
+func storage(ctx context.Context) (domain.ProductRepository, error) {
+	uri := os.Getenv(MongoDBURIEnv)
+	if uri == "" {
+		return repository.NewMemoryProductRepository(), nil
+	}
+
+	client, err := mongodb.NewMongoDBClient(ctx, mongodb.Config{URI: uri, Database: "products_e2e"})
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", MongoDBURIEnv, err)
+	}
+	migrator, err := mongodb.NewMigrator(client, migrations.All...)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		return nil, fmt.Errorf("migrate %s: %w", MongoDBURIEnv, err)
+	}
+	return repository.NewMongodbProductRepository(client), nil
+}
+
+func logOutput(opts Options) io.Writer {
+	if opts.LogOutput == nil {
+		return io.Discard
+	}
+	return opts.LogOutput
+}
+
+func meterProvider(opts Options) metric.MeterProvider {
+	if opts.MeterProvider == nil {
+		return noop.NewMeterProvider()
+	}
+	return opts.MeterProvider
+}
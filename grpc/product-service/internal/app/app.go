@@ -4,18 +4,24 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
+	"log/slog"
 	"net/http"
 	"product-service/internal/config"
 	grpcservices "product-service/internal/delivery/grpc"
 	"product-service/internal/repository"
 	"product-service/internal/usecase"
+	"product-service/migrations"
 	"product-service/pb"
+	"time"
 
 	"common-service/pkg/logger"
+	"common-service/pkg/metrics"
+	"common-service/pkg/server"
 	"common-service/pkg/trace"
 
+	pkgconfig "common-service/pkg/config"
 	"common-service/pkg/db/mongodb"
+	"common-service/pkg/reqctx"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -23,49 +29,110 @@ import (
 
 type App struct {
 	ctx        context.Context
+	opts       options
 	grpcServer *grpc.Server
 
-	tp         *trace.Tracer
+	tp            *trace.Tracer
+	meter         *metrics.Meter
+	reloader      *pkgconfig.Reloader[config.Config]
+	mongodbClient *mongodb.MongoClient
+
+	server.Listeners
+
 	httpServer *http.ServeMux
+	httpSrv    *http.Server
 }
 
-func NewApp(ctx context.Context) (*App, error) {
+func NewApp(ctx context.Context, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-		return nil, err
+	cfg := o.config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+			return nil, err
+		}
 	}
 
 	// logging
-	logger.InitLogger("debug")
+	if o.logOutput != nil {
+		logger.InitLoggerTo(cfg.App.LogLevel, o.logOutput)
+	} else {
+		logger.InitLogger(cfg.App.LogLevel)
+	}
+	cfg.LogEffective(slog.Default())
 
 	// tracing
-	tp, err := trace.InitTracer(ctx, cfg.App.Trace.Endpoint, "PRODUCT_SERVICE")
-	if err != nil {
-		log.Fatalf("Failed to initialize tracer: %v", err)
-		return nil, err
+	var tp *trace.Tracer
+	tracerProvider := o.tracerProvider
+	if tracerProvider == nil {
+		var err error
+		tp, err = trace.InitTracer(ctx, cfg.App.Trace.Endpoint, "PRODUCT_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize tracer: %v", err)
+			return nil, err
+		}
+		tp.SetSampleRatio(cfg.App.Trace.Ratio())
+		tracerProvider = tp.TracerProvider
 	}
 
-	// mongodb
-	mongodbClient, err := mongodb.NewMongoDBClient(mongodb.Config{
-		URI:      cfg.DB.MongoDB.URI,
-		Database: cfg.DB.MongoDB.Database,
-	})
+	// metrics
+	var meter *metrics.Meter
+	meterProvider := o.meterProvider
+	if meterProvider == nil {
+		var err error
+		meter, err = metrics.InitMeter(ctx, cfg.App.Metrics, "PRODUCT_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize meter: %v", err)
+			return nil, err
+		}
+		meterProvider = meter.MeterProvider
+	}
+
+	// listeners
+	listeners, err := server.Listen(server.Listeners{GRPC: o.grpcListener, HTTP: o.httpListener}, cfg.GRPC, cfg.HTTP)
 	if err != nil {
-		log.Fatalf("Failed to initialize MongoDB: %v", err)
 		return nil, err
 	}
 
 	// repository
-	productRepository := repository.NewMongodbProductRepository(mongodbClient)
+	productRepository := o.productRepository
+	var mongodbClient *mongodb.MongoClient
+	if productRepository == nil {
+		// mongodb
+		mongodbClient, err = mongodb.NewMongoDBClient(ctx, cfg.DB.MongoDB)
+		if err != nil {
+			log.Fatalf("Failed to initialize MongoDB: %v", err)
+			return nil, err
+		}
+
+		// migrations
+		migrator, err := mongodb.NewMigrator(mongodbClient, migrations.All...)
+		if err != nil {
+			log.Fatalf("Failed to load MongoDB migrations: %v", err)
+			return nil, err
+		}
+		if err := migrator.Migrate(ctx); err != nil {
+			log.Fatalf("Failed to apply MongoDB migrations: %v", err)
+			return nil, err
+		}
+
+		productRepository = repository.NewMongodbProductRepository(mongodbClient)
+	}
 
 	// usecase
 	productUsecase := usecase.NewProductUsecase(productRepository)
 
 	// grpc server
 	grpcServer := grpc.NewServer(
-		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tracerProvider), otelgrpc.WithMeterProvider(meterProvider))),
+		grpc.ChainUnaryInterceptor(reqctx.UnaryServerInterceptor(reqctx.DefaultPolicy())),
 	)
 
 	// register services
@@ -74,23 +141,34 @@ func NewApp(ctx context.Context) (*App, error) {
 	// http server
 	httpServer := http.NewServeMux()
 
+	// hot reload
+	reloader := cfg.NewReloader()
+	reloader.Subscribe("app.log_level", func(_, next *config.Config) {
+		logger.SetLevel(next.App.LogLevel)
+	})
+	if tp != nil {
+		reloader.Subscribe("app.trace.sample_ratio", func(_, next *config.Config) {
+			tp.SetSampleRatio(next.App.Trace.Ratio())
+		})
+	}
+
 	return &App{
-		ctx:        ctx,
-		grpcServer: grpcServer,
-		tp:         tp,
-		httpServer: httpServer,
+		ctx:           ctx,
+		opts:          o,
+		grpcServer:    grpcServer,
+		tp:            tp,
+		meter:         meter,
+		reloader:      reloader,
+		mongodbClient: mongodbClient,
+		Listeners:     listeners,
+		httpServer:    httpServer,
 	}, nil
 }
 
 func (a *App) Run() error {
-	lis, err := net.Listen("tcp", ":50053")
-	if err != nil {
-		return err
-	}
-
 	go func() {
-		fmt.Println("Starting gRPC server on port 50053")
-		if err := a.grpcServer.Serve(lis); err != nil {
+		fmt.Printf("Starting gRPC server on %s \n", a.GRPCAddr())
+		if err := a.grpcServer.Serve(a.GRPC); err != nil {
 			log.Fatalf("Failed to serve gRPC server: %v", err)
 		}
 	}()
@@ -98,20 +176,50 @@ func (a *App) Run() error {
 	handlerWithCORS := withCORS(a.httpServer)
 
 	// Start HTTP server in a separate goroutine
+	a.httpSrv = &http.Server{Handler: handlerWithCORS}
 	go func() {
-		httpPort := ":8082"
-		fmt.Printf("HTTP server starting on port %s \n", httpPort)
-		if err := http.ListenAndServe(httpPort, handlerWithCORS); err != nil {
+		fmt.Printf("HTTP server starting on %s \n", a.HTTPAddr())
+		if err := a.httpSrv.Serve(a.HTTP); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start HTTP server: %v", err)
 		}
 	}()
 
+	// apply config file changes until the context is done
+	go func() {
+		if err := a.reloader.Watch(a.ctx); err != nil {
+			slog.Error("Config watcher stopped", "error", err)
+		}
+	}()
+
 	<-a.ctx.Done()
 	return nil
 }
 
 func (a *App) Shutdown() error {
 	a.grpcServer.Stop()
+	if err := a.CloseHTTP(context.Background(), a.httpSrv); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+
+	if a.mongodbClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := a.mongodbClient.Close(ctx); err != nil {
+			log.Printf("Error closing MongoDB client: %v", err)
+		}
+	}
+
+	// providers passed in through options belong to the caller
+	if a.tp != nil {
+		if err := a.tp.TracerProvider.Shutdown(context.Background()); err != nil {
+			log.Printf("Error shutting down tracer provider: %v", err)
+		}
+	}
+	if a.meter != nil {
+		if err := a.meter.Shutdown(context.Background()); err != nil {
+			log.Printf("Error shutting down meter provider: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"product-service/internal/config"
+	"product-service/internal/repository"
+	"product-service/pb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestApp_ServesGRPCAndCORS(t *testing.T) {
+	t.Chdir(t.TempDir()) // the logger writes logs/app.log
+
+	cfg := &config.Config{
+		App:  config.AppConfig{Name: "product-service"},
+		HTTP: config.HTTPConfig{Host: "127.0.0.1"},
+		GRPC: config.GRPCConfig{Host: "127.0.0.1"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := NewApp(ctx,
+		WithConfig(cfg),
+		WithTracerProvider(noop.NewTracerProvider()), WithMeterProvider(metricnoop.NewMeterProvider()),
+		WithProductRepository(repository.NewMemoryProductRepository()),
+	)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		assert.NoError(t, a.Shutdown())
+	})
+
+	conn, err := grpc.NewClient(a.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := pb.NewProductServiceClient(conn).GetProduct(ctx, &pb.GetProductRequest{Id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", resp.GetProduct().GetId())
+
+	// preflight requests are answered by the CORS middleware
+	req, err := http.NewRequest(http.MethodOptions, "http://"+a.HTTPAddr()+"/", nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"io"
+	"net"
+	"product-service/internal/config"
+	"product-service/internal/domain"
+
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Option changes how NewApp wires the service. Without options the service
+// runs as deployed; the options let tests run it in process.
+type Option func(*options)
+
+type options struct {
+	config            *config.Config
+	tracerProvider    oteltrace.TracerProvider
+	meterProvider     metric.MeterProvider
+	grpcListener      net.Listener
+	httpListener      net.Listener
+	logOutput         io.Writer
+	productRepository domain.ProductRepository
+}
+
+// WithConfig uses cfg as is instead of loading and validating the config
+// files.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) { o.config = cfg }
+}
+
+// WithTracerProvider instruments the service with tp instead of setting up
+// an OTLP exporter. The caller owns tp.
+func WithTracerProvider(tp oteltrace.TracerProvider) Option {
+	return func(o *options) { o.tracerProvider = tp }
+}
+
+// WithMeterProvider records the service metrics with mp instead of setting
+// up an OTLP exporter. The caller owns mp.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) { o.meterProvider = mp }
+}
+
+// WithLogOutput sends the service logs to w instead of stdout and
+// logs/app.log.
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) { o.logOutput = w }
+}
+
+func WithGRPCListener(lis net.Listener) Option {
+	return func(o *options) { o.grpcListener = lis }
+}
+
+func WithHTTPListener(lis net.Listener) Option {
+	return func(o *options) { o.httpListener = lis }
+}
+
+// WithProductRepository replaces the MongoDB repository; no connection is
+// opened.
+func WithProductRepository(repo domain.ProductRepository) Option {
+	return func(o *options) { o.productRepository = repo }
+}
@@ -1,73 +1,67 @@
 package config
 
 import (
-	"fmt"
-	"strings"
+	"log/slog"
 
-	"github.com/spf13/viper"
+	pkgconfig "common-service/pkg/config"
+	"common-service/pkg/db/mongodb"
+	"common-service/pkg/metrics"
+	"common-service/pkg/server"
+	"common-service/pkg/trace"
 )
 
+// EnvPrefix namespaces environment overrides, e.g. PRODUCT_SERVICE_HTTP_PORT.
+const EnvPrefix = "PRODUCT_SERVICE"
+
 type Config struct {
 	App  AppConfig  `mapstructure:"app"`
 	HTTP HTTPConfig `mapstructure:"http"`
 	GRPC GRPCConfig `mapstructure:"grpc"`
 	DB   Database   `mapstructure:"database"`
-}
 
-type TraceConfig struct {
-	Endpoint string `mapstructure:"endpoint"`
+	source pkgconfig.Source
 }
 
-type AppConfig struct {
-	Name     string      `mapstructure:"name"`
-	LogLevel string      `mapstructure:"log_level"`
-	Trace    TraceConfig `mapstructure:"trace"`
-}
+type TraceConfig = trace.Config
 
-type HTTPConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-}
+type MetricsConfig = metrics.Config
 
-type GRPCConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
+type AppConfig struct {
+	Name     string        `mapstructure:"name" validate:"required"`
+	LogLevel string        `mapstructure:"log_level" validate:"omitempty,oneof=debug info warn error"`
+	Trace    TraceConfig   `mapstructure:"trace"`
+	Metrics  MetricsConfig `mapstructure:"metrics"`
 }
 
-type Database struct {
-	MongoDB MongoDBConfig `mapstructure:"mongodb"`
-}
+type (
+	HTTPConfig = server.Config
+	GRPCConfig = server.Config
+)
 
-type MongoDBConfig struct {
-	URI         string `mapstructure:"uri"`
-	Database    string `mapstructure:"database"`
-	Username    string `mapstructure:"username"`
-	Password    string `mapstructure:"password"`
-	AuthSource  string `mapstructure:"auth_source"`
-	Timeout     string `mapstructure:"timeout"`
-	MaxPoolSize int    `mapstructure:"max_pool_size"`
+type Database struct {
+	MongoDB mongodb.Config `mapstructure:"mongodb"`
 }
 
+// Load reads configs/base.yaml, the APP_ENV profile and local overrides,
+// applies PRODUCT_SERVICE_* env vars and validates the result.
 func Load() (*Config, error) {
-	viper.SetConfigName("dev")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
-
-	// allow overriding via env vars like APP_NAME, HTTP_PORT
-	viper.AutomaticEnv()
-	// viper.SetEnvPrefix("PRODUCT_SERVICE")
-
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %v", err)
-	}
-
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
+	src, err := pkgconfig.Load(&cfg, pkgconfig.Options{EnvPrefix: EnvPrefix})
+	if err != nil {
+		return nil, err
 	}
+	cfg.source = src
 
 	return &cfg, nil
 }
+
+// NewReloader returns a reloader starting from c that loads the config
+// again when its files change, see pkgconfig.Reloader.
+func (c *Config) NewReloader() *pkgconfig.Reloader[Config] {
+	return pkgconfig.NewReloader(c, c.source, Load)
+}
+
+// LogEffective logs the config with secrets redacted.
+func (c *Config) LogEffective(logger *slog.Logger) {
+	pkgconfig.LogEffective(logger, c.source, c)
+}
@@ -2,6 +2,7 @@ package grpcservices
 
 import (
 	"context"
+	"errors"
 	"product-service/internal/domain"
 	"product-service/pb"
 )
@@ -26,7 +27,8 @@ func (s *ProductGrpcService) GetProduct(ctx context.Context, req *pb.GetProductR
 		Price:       100,
 		Quantity:    100,
 	})
-	if err != nil {
+	// the fixed product is stored by the first call
+	if err != nil && !errors.Is(err, domain.ErrProductExists) {
 		return nil, err
 	}
 
@@ -0,0 +1,40 @@
+package grpcservices
+
+import (
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"product-service/internal/domain"
+	"product-service/pb"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type fakeProductUsecase struct{}
+
+func (u *fakeProductUsecase) CreateProduct(ctx context.Context, product *domain.Product) error {
+	_, span := trace.StartSpan(ctx, "ProductUsecase.CreateProduct")
+	defer span.End()
+
+	return nil
+}
+
+func TestProductGrpcService_GetProduct(t *testing.T) {
+	rec := tracetest.Install(t)
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		pb.RegisterProductServiceServer(s, NewProductGrpcService(&fakeProductUsecase{}))
+	})
+
+	resp, err := pb.NewProductServiceClient(conn).GetProduct(context.Background(), &pb.GetProductRequest{Id: "1"})
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if resp.GetProduct().GetId() != "1" {
+		t.Errorf("product id = %q, want 1", resp.GetProduct().GetId())
+	}
+
+	rec.ServerSpan(t, "product.ProductService/GetProduct").
+		HasParent("product.ProductService/GetProduct").
+		HasChild("ProductUsecase.CreateProduct")
+}
@@ -1,6 +1,13 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrProductExists is returned by CreateProduct for a product ID that is
+// already stored.
+var ErrProductExists = errors.New("product already exists")
 
 type ProductRepository interface {
 	CreateProduct(ctx context.Context, product *Product) error
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"common-service/pkg/trace"
+	"context"
+	"product-service/internal/domain"
+	"sync"
+)
+
+// memoryProductRepository keeps products in memory. It stands in for MongoDB
+// when the service runs in process.
+type memoryProductRepository struct {
+	mu       sync.Mutex
+	products map[string]domain.Product
+}
+
+func NewMemoryProductRepository() *memoryProductRepository {
+	return &memoryProductRepository{products: make(map[string]domain.Product)}
+}
+
+func (r *memoryProductRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+	_, span := trace.StartSpan(ctx, "MemoryProductRepository.CreateProduct")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[product.ID]; ok {
+		return domain.ErrProductExists
+	}
+	r.products[product.ID] = *product
+	return nil
+}
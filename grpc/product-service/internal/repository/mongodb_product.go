@@ -5,6 +5,9 @@ import (
 	"common-service/pkg/trace"
 	"context"
 	"product-service/internal/domain"
+	"product-service/migrations"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type mongodbProductRepository struct {
@@ -17,13 +20,16 @@ func NewMongodbProductRepository(mongodbClient *mongodb.MongoClient) *mongodbPro
 	}
 }
 
-func (r *mongodbProductRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+func (r *mongodbProductRepository) CreateProduct(ctx context.Context, product *domain.Product) (err error) {
 	ctx, span := trace.StartSpan(ctx, "MongodbProductRepository.CreateProduct")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
-	collection := r.mongodbClient.DB.Collection("products")
+	collection := r.mongodbClient.DB.Collection(migrations.ProductsCollection)
 
-	_, err := collection.InsertOne(ctx, product)
+	_, err = collection.InsertOne(ctx, product)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrProductExists
+	}
 	if err != nil {
 		return err
 	}
@@ -14,9 +14,9 @@ func NewProductUsecase(productRepository domain.ProductRepository) *productUseca
 	return &productUsecase{productRepository: productRepository}
 }
 
-func (u *productUsecase) CreateProduct(ctx context.Context, product *domain.Product) error {
+func (u *productUsecase) CreateProduct(ctx context.Context, product *domain.Product) (err error) {
 	ctx, span := trace.StartSpan(ctx, "ProductUsecase.CreateProduct")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
 	return u.productRepository.CreateProduct(ctx, product)
 }
@@ -1 +1,66 @@
 package usecase
+
+import (
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"product-service/internal/domain"
+	"product-service/internal/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProductUsecase_CreateProduct_Table(t *testing.T) {
+	errDuplicate := errors.New("duplicate key")
+
+	tests := []struct {
+		name    string
+		product *domain.Product
+		repoErr error
+		wantErr error
+	}{
+		{
+			name:    "stores product",
+			product: &domain.Product{ID: "1", Name: "keyboard", Price: 49.9},
+		},
+		{
+			name:    "repository error",
+			product: &domain.Product{ID: "1"},
+			repoErr: errDuplicate,
+			wantErr: errDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockProductRepository(t)
+			repo.EXPECT().CreateProduct(mock.Anything, tt.product).Return(tt.repoErr)
+
+			err := NewProductUsecase(repo).CreateProduct(context.Background(), tt.product)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestProductUsecase_CreateProduct(t *testing.T) {
+	rec := tracetest.Install(t)
+	repo := mocks.NewMockProductRepository(t)
+	repo.EXPECT().CreateProduct(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, product *domain.Product) error {
+		_, span := trace.StartSpan(ctx, "MongodbProductRepository.CreateProduct")
+		defer span.End()
+
+		return nil
+	})
+	uc := NewProductUsecase(repo)
+
+	if err := uc.CreateProduct(context.Background(), &domain.Product{ID: "1"}); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	rec.Span(t, "ProductUsecase.CreateProduct").
+		IsRoot().
+		HasChild("MongodbProductRepository.CreateProduct")
+}
@@ -0,0 +1,119 @@
+// Package migrations declares the MongoDB collections, validators and
+// indexes of the product service. The app applies them at startup.
+//
+// Product fields have no bson tags, so documents use the lowercased Go
+// field names: id, name, description, price, quantity, createdat, updatedat.
+package migrations
+
+import (
+	"common-service/pkg/db/mongodb"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProductsCollection holds domain.Product documents.
+const ProductsCollection = "products"
+
+// All lists the migrations in version order. Append new versions; never
+// edit one that has shipped.
+var All = []mongodb.Migration{
+	{
+		Version:     1,
+		Description: "products collection with validator",
+		Collections: []mongodb.Collection{{
+			Name: ProductsCollection,
+			Schema: bson.M{
+				"bsonType": "object",
+				"required": bson.A{"id", "name", "price", "quantity"},
+				"properties": bson.M{
+					"id":          bson.M{"bsonType": "string"},
+					"name":        bson.M{"bsonType": "string", "minLength": 1},
+					"description": bson.M{"bsonType": "string"},
+					"price":       bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}, "minimum": 0},
+					"quantity":    bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+					"createdat":   bson.M{"bsonType": "date"},
+					"updatedat":   bson.M{"bsonType": "date"},
+				},
+			},
+			Indexes: []mongodb.Index{
+				// not unique yet: GetProduct still stores its fixed product
+				// on every call
+				{Name: "products_id", Keys: bson.D{{Key: "id", Value: 1}}},
+				{Name: "products_name_price", Keys: bson.D{{Key: "name", Value: 1}, {Key: "price", Value: 1}}},
+			},
+		}},
+	},
+	{
+		Version:     2,
+		Description: "products text search",
+		Collections: []mongodb.Collection{{
+			Name: ProductsCollection,
+			Indexes: []mongodb.Index{{
+				Name:            "products_text",
+				Keys:            bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+				Weights:         bson.D{{Key: "name", Value: 10}, {Key: "description", Value: 1}},
+				DefaultLanguage: "english",
+			}},
+		}},
+	}, {
+		Version:     3,
+		Description: "unique product ids",
+		Up:          uniqueProductIDs,
+	},
+}
+
+// uniqueProductIDs removes the copies of its fixed product GetProduct stored
+// on every call, keeping the oldest document per id, and then rebuilds
+// products_id as a unique index. Dropping and creating the index again is
+// safe should the migration be retried.
+func uniqueProductIDs(ctx context.Context, db *mongo.Database) error {
+	products := db.Collection(ProductsCollection)
+
+	cursor, err := products.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$id"},
+			{Key: "docs", Value: bson.M{"$push": "$_id"}},
+		}}},
+		{{Key: "$match", Value: bson.M{"docs.1": bson.M{"$exists": true}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("find duplicate products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group struct {
+			Docs []any `bson:"docs"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		if _, err := products.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.Docs[1:]}}); err != nil {
+			return fmt.Errorf("delete duplicate products: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// an index cannot be made unique in place
+	_, err = products.Indexes().DropOne(ctx, "products_id")
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound") {
+		return fmt.Errorf("drop products_id: %w", err)
+	}
+	_, err = products.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetName("products_id").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("create products_id: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"common-service/pkg/db/mongodb"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestUniqueProductIDs_RemovesDuplicates(t *testing.T) {
+	uri := os.Getenv("E2E_MONGODB_URI")
+	if uri == "" {
+		t.Skip("E2E_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongodb.NewMongoDBClient(ctx, mongodb.Config{URI: uri, Database: fmt.Sprintf("products_test_%d", time.Now().UnixNano())})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.DB.Drop(ctx)
+		_ = client.Close(ctx)
+	})
+
+	// a deployment that stored the fixed product on every GetProduct
+	before, err := mongodb.NewMigrator(client, All[:2]...)
+	require.NoError(t, err)
+	require.NoError(t, before.Migrate(ctx))
+
+	products := client.DB.Collection(ProductsCollection)
+	for _, id := range []string{"1", "1", "2", "1"} {
+		_, err := products.InsertOne(ctx, bson.M{"id": id, "name": "Product " + id, "price": 100, "quantity": 100})
+		require.NoError(t, err)
+	}
+
+	migrator, err := mongodb.NewMigrator(client, All...)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(ctx))
+
+	for _, id := range []string{"1", "2"} {
+		n, err := products.CountDocuments(ctx, bson.M{"id": id})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n, "documents with id %s", id)
+	}
+
+	_, err = products.InsertOne(ctx, bson.M{"id": "1", "name": "Product 1", "price": 100, "quantity": 100})
+	assert.True(t, mongo.IsDuplicateKeyError(err), "products_id is unique, got %v", err)
+}
@@ -0,0 +1,136 @@
+// Package apptest runs the user service in process for integration tests.
+// Storage is in memory unless E2E_POSTGRES_DSN points at a Postgres
+// database, in which case the real repositories and migrations are used.
+package apptest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+	"user-service/internal/app"
+	"user-service/internal/config"
+	"user-service/internal/domain"
+	"user-service/internal/repository"
+	"user-service/migrations"
+
+	"common-service/pkg/db"
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/idempotency"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// PostgresDSNEnv names the variable holding a DSN for a real database.
+const PostgresDSNEnv = "E2E_POSTGRES_DSN"
+
+type Options struct {
+	// Listener serves gRPC, usually a bufconn listener.
+	Listener net.Listener
+	// ProductTarget and ProductDialOptions reach the product service.
+	ProductTarget      string
+	ProductDialOptions []grpc.DialOption
+	TracerProvider     oteltrace.TracerProvider
+	// MeterProvider records the service metrics, which are dropped when
+	// nil.
+	MeterProvider metric.MeterProvider
+	// LogOutput receives the service logs, which are discarded when nil.
+	LogOutput io.Writer
+}
+
+type Service struct {
+	app    *app.App
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// Start wires the service like cmd/server.go does and serves it until Stop.
+func Start(ctx context.Context, opts Options) (*Service, error) {
+	users, outbox, store, err := storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config.Config{
+		Clients: config.ClientsConfig{
+			ProductService: grpcclient.Config{
+				Target:   opts.ProductTarget,
+				Timeouts: grpcclient.TimeoutsConfig{RPC: 5 * time.Second},
+			},
+		},
+		Outbox:      config.OutboxConfig{PollInterval: 50 * time.Millisecond, BatchSize: 10},
+		Idempotency: config.IdempotencyConfig{TTL: time.Hour},
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	a, err := app.NewApp(ctx,
+		app.WithConfig(cfg),
+		app.WithTracerProvider(opts.TracerProvider),
+		app.WithMeterProvider(meterProvider(opts)),
+		app.WithLogOutput(logOutput(opts)),
+		app.WithGRPCListener(opts.Listener),
+		app.WithHTTPListener(httpListener),
+		app.WithProductDialOptions(opts.ProductDialOptions...),
+		app.WithRepositories(users, outbox, store),
+	)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &Service{app: a, cancel: cancel, done: make(chan error, 1)}
+	go func() { s.done <- a.Run() }()
+
+	return s, nil
+}
+
+// Stop cancels the service, waits for Run to return and shuts it down.
+func (s *Service) Stop() error {
+	s.cancel()
+	if err := <-s.done; err != nil {
+		return err
+	}
+	return s.app.Shutdown()
+}
+
+func storage(ctx context.Context) (domain.UserRepository, domain.OutboxRepository, idempotency.Store, error) {
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		users := repository.NewMemoryUserRepository()
+		return users, users, idempotency.NewMemoryStore(), nil
+	}
+
+	conn, err := db.InitDB(ctx, db.Config{Driver: "postgres"}, dsn)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("connect to %s: %w", PostgresDSNEnv, err)
+	}
+	if err := db.ApplyMigrations(ctx, conn, "postgres", migrations.FS); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return repository.NewUserRepository(db.NewCluster(conn, nil, db.ReplicaConfig{})), repository.NewOutboxRepository(conn), idempotency.NewPostgresStore(conn, "idempotency_keys"), nil
+}
+
+func logOutput(opts Options) io.Writer {
+	if opts.LogOutput == nil {
+		return io.Discard
+	}
+	return opts.LogOutput
+}
+
+func meterProvider(opts Options) metric.MeterProvider {
+	if opts.MeterProvider == nil {
+		return noop.NewMeterProvider()
+	}
+	return opts.MeterProvider
+}
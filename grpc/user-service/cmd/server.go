@@ -2,16 +2,41 @@ package main
 
 import (
 	"context"
+	"flag"
+	"log"
+	"os"
 	"os/signal"
 	"syscall"
 	"user-service/internal/app"
+
+	"common-service/pkg/db"
 )
 
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	app, err := app.NewApp(ctx)
+	// server migrate up|down|status|redo|create NAME
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.Migrate(ctx, os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	migrationMode := flag.String("migrations", "", "what startup does about pending migrations: migrate, verify or skip (default database.migrations.mode)")
+	flag.Parse()
+
+	var opts []app.Option
+	if *migrationMode != "" {
+		mode, err := db.ParseMigrationMode(*migrationMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, app.WithMigrationMode(mode))
+	}
+
+	app, err := app.NewApp(ctx, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -2,88 +2,221 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
-	"net"
 	"net/http"
 	"os"
+	productpb "product-service/pb"
+	"time"
 	"user-service/internal/config"
 	grpcservices "user-service/internal/delivery/grpc"
+	"user-service/internal/domain"
+	"user-service/internal/outbox"
 	"user-service/internal/repository"
 	"user-service/internal/usecase"
+	"user-service/migrations"
 	"user-service/pb"
 
 	"common-service/pkg/logger"
+	"common-service/pkg/metrics"
+	"common-service/pkg/server"
 	"common-service/pkg/trace"
 
+	pkgconfig "common-service/pkg/config"
 	"common-service/pkg/db"
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/idempotency"
+	"common-service/pkg/reqctx"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type App struct {
 	ctx        context.Context
+	opts       options
 	grpcServer *grpc.Server
 
 	grpcClient        *grpc.ClientConn
-	productGrpcClient pb.ProductServiceClient
+	productGrpcClient productpb.ProductServiceClient
 
-	tp *trace.Tracer
+	tp       *trace.Tracer
+	meter    *metrics.Meter
+	reloader *pkgconfig.Reloader[config.Config]
+
+	dbCluster *db.Cluster
+
+	outboxRelay *outbox.Relay
+
+	idempotencyStore   idempotency.Store
+	idempotencyCleanup time.Duration
+
+	server.Listeners
 
 	httpServer *http.ServeMux
+	httpSrv    *http.Server
 }
 
-func NewApp(ctx context.Context) (*App, error) {
+func NewApp(ctx context.Context, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.userRepository != nil && (o.outboxRepository == nil || o.idempotencyStore == nil) {
+		return nil, errors.New("WithRepositories needs an outbox repository and an idempotency store")
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-		return nil, err
+	cfg := o.config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+			return nil, err
+		}
 	}
 
 	// logging
-	logger.InitLogger("debug")
+	if o.logOutput != nil {
+		logger.InitLoggerTo(cfg.App.LogLevel, o.logOutput)
+	} else {
+		logger.InitLogger(cfg.App.LogLevel)
+	}
+	cfg.LogEffective(slog.Default())
 
 	// tracing
-	tp, err := trace.InitTracer(ctx, cfg.App.Trace.Endpoint, "USER_SERVICE")
-	if err != nil {
-		log.Fatalf("Failed to initialize tracer: %v", err)
-		return nil, err
+	var tp *trace.Tracer
+	tracerProvider := o.tracerProvider
+	if tracerProvider == nil {
+		var err error
+		tp, err = trace.InitTracer(ctx, cfg.App.Trace.Endpoint, "USER_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize tracer: %v", err)
+			return nil, err
+		}
+		tp.SetSampleRatio(cfg.App.Trace.Ratio())
+		tracerProvider = tp.TracerProvider
 	}
 
-	// db
-	dbConn, err := db.InitDB(ctx, "postgres", config.GetDatabaseDSN(cfg))
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// metrics
+	var meter *metrics.Meter
+	meterProvider := o.meterProvider
+	if meterProvider == nil {
+		var err error
+		meter, err = metrics.InitMeter(ctx, cfg.App.Metrics, "USER_SERVICE")
+		if err != nil {
+			log.Fatalf("Failed to initialize meter: %v", err)
+			return nil, err
+		}
+		meterProvider = meter.MeterProvider
 	}
 
-	// apply migrations
-	err = db.ApplyMigrations(dbConn, "postgres", "migrations")
+	// listeners
+	listeners, err := server.Listen(server.Listeners{GRPC: o.grpcListener, HTTP: o.httpListener}, cfg.GRPC, cfg.HTTP)
 	if err != nil {
-		log.Fatalf("Failed to apply migrations: %v", err)
+		return nil, err
+	}
+
+	// repository
+	userRepository, outboxRepository, idempotencyStore := o.userRepository, o.outboxRepository, o.idempotencyStore
+	var dbCluster *db.Cluster
+	if userRepository == nil {
+		// db
+		dbCfg := config.GetDatabaseConfig(cfg)
+		dbCfg.MeterProvider = meterProvider
+		dsn := db.NewDSN(config.GetDatabaseDSN(cfg))
+		dbConn, err := db.InitDBWithDSN(ctx, dbCfg, dsn)
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+
+		// read replicas
+		var replicas []db.Replica
+		replicaDSNs := map[*db.DSN]config.PostgresReplicaConfig{}
+		for _, rc := range cfg.DB.Postgres.Replicas {
+			// a replica that is down is read from once the cluster checks
+			// find it healthy
+			replicaDSN := db.NewDSN(config.GetReplicaDSN(cfg, rc))
+			replicaCfg := dbCfg
+			replicaCfg.Node = rc.Name
+			replicaConn, err := db.OpenDBWithDSN(replicaCfg, replicaDSN)
+			if err != nil {
+				log.Fatalf("Failed to open replica %s: %v", rc.Name, err)
+			}
+			replicas = append(replicas, db.Replica{Name: rc.Name, DB: replicaConn})
+			replicaDSNs[replicaDSN] = rc
+		}
+		dbCluster = db.NewCluster(dbConn, replicas, cfg.DB.Postgres.Replication)
+
+		// new connections pick up rotated credentials
+		rotated := *cfg
+		go cfg.WatchSecrets(ctx, func(key, value string) {
+			switch key {
+			case "database.postgres.username":
+				rotated.DB.Postgres.Username = value
+			case "database.postgres.password":
+				rotated.DB.Postgres.Password = value
+			default:
+				return
+			}
+			dsn.Set(config.GetDatabaseDSN(&rotated))
+			for replicaDSN, rc := range replicaDSNs {
+				replicaDSN.Set(config.GetReplicaDSN(&rotated, rc))
+			}
+			slog.Info("Rotated database credentials", "key", key)
+		})
+
+		// migrations
+		mode := cfg.DB.Migrations.Mode
+		if o.migrationMode != "" {
+			mode = o.migrationMode
+		}
+		migrator, err := db.NewMigrator(dbConn, "postgres", migrations.FS)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		if err := migrator.Run(ctx, mode); err != nil {
+			log.Fatalf("Failed to %s migrations: %v", mode, err)
+		}
+
+		userRepository = repository.NewUserRepository(dbCluster)
+		outboxRepository = repository.NewOutboxRepository(dbConn)
+		idempotencyStore = idempotency.NewPostgresStore(dbConn, "idempotency_keys")
 	}
 
 	// grpc client
-	grpcClient, err := grpc.NewClient(fmt.Sprintf("%s:%d", cfg.Clients.ProductService.Target, cfg.Clients.ProductService.Port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-	)
+	productCfg := cfg.Clients.ProductService
+	productCfg.MeterProvider = meterProvider
+	grpcClient, productTimeout, err := grpcclient.NewClientWithTimeout(productCfg, o.productDialOptions...)
 	if err != nil {
 		slog.Error("Failed to create grpc client", "error", err)
 		return nil, err
 	}
-	productGrpcClient := pb.NewProductServiceClient(grpcClient)
+	productGrpcClient := productpb.NewProductServiceClient(grpcClient)
 
 	// grpc server
 	grpcServer := grpc.NewServer(
-		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tracerProvider), otelgrpc.WithMeterProvider(meterProvider))),
+		grpc.ChainUnaryInterceptor(
+			reqctx.UnaryServerInterceptor(reqctx.DefaultPolicy()),
+			idempotency.UnaryServerInterceptor(idempotency.Options{
+				Store:   idempotencyStore,
+				Methods: []string{pb.UserService_CreateUser_FullMethodName},
+				TTL:     cfg.Idempotency.TTL,
+				Lease:   cfg.Idempotency.Lease,
+			}),
+		),
 	)
 
-	// repository
-	userRepository := repository.NewUserRepository(dbConn)
+	// outbox relay
+	outboxRelay := outbox.NewRelay(outboxRepository, outbox.NewLogPublisher(), cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, domain.OutboxRetryPolicy{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		MinBackoff:  cfg.Outbox.MinBackoff,
+		MaxBackoff:  cfg.Outbox.MaxBackoff,
+	})
 
 	// usecase
 	userUsecase := usecase.NewUserUsecase(productGrpcClient, userRepository)
@@ -94,26 +227,58 @@ func NewApp(ctx context.Context) (*App, error) {
 	// http server
 	httpServer := http.NewServeMux()
 
+	// hot reload
+	reloader := cfg.NewReloader()
+	reloader.Subscribe("app.log_level", func(_, next *config.Config) {
+		logger.SetLevel(next.App.LogLevel)
+	})
+	if tp != nil {
+		reloader.Subscribe("app.trace.sample_ratio", func(_, next *config.Config) {
+			tp.SetSampleRatio(next.App.Trace.Ratio())
+		})
+	}
+	reloader.Subscribe("clients.product_service.timeouts.rpc", func(_, next *config.Config) {
+		productTimeout.Set(next.Clients.ProductService.Timeouts.RPC)
+	})
+
 	return &App{
-		ctx:               ctx,
-		grpcClient:        grpcClient,
-		productGrpcClient: productGrpcClient,
-		tp:                tp,
-		grpcServer:        grpcServer,
-		httpServer:        httpServer,
+		ctx:                ctx,
+		opts:               o,
+		grpcClient:         grpcClient,
+		productGrpcClient:  productGrpcClient,
+		tp:                 tp,
+		meter:              meter,
+		reloader:           reloader,
+		dbCluster:          dbCluster,
+		outboxRelay:        outboxRelay,
+		idempotencyStore:   idempotencyStore,
+		idempotencyCleanup: cfg.Idempotency.CleanupInterval,
+		grpcServer:         grpcServer,
+		Listeners:          listeners,
+		httpServer:         httpServer,
 	}, nil
 }
 
 func (a *App) Run() error {
-	port := ":50051"
+	go func() {
+		fmt.Printf("User service listening on %s \n", a.GRPCAddr())
+		if err := a.grpcServer.Serve(a.GRPC); err != nil {
+			log.Fatal(err)
+		}
+	}()
 
-	fmt.Printf("User service starting on port %s \n", port)
-	lis, err := net.Listen("tcp", port)
-	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+	// relay outbox events in the background
+	go a.outboxRelay.Run(a.ctx)
+
+	// delete idempotency keys the store keeps after they expire
+	if expirer, ok := a.idempotencyStore.(idempotency.Expirer); ok {
+		go idempotency.RunCleanup(a.ctx, expirer, a.idempotencyCleanup)
 	}
 
-	go func() { log.Fatal(a.grpcServer.Serve(lis)) }()
+	// keep reads off unhealthy or lagging replicas
+	if a.dbCluster != nil {
+		go a.dbCluster.Watch(a.ctx)
+	}
 
 	// swagger endpoint
 	a.httpServer.HandleFunc("/user/swagger.json", func(w http.ResponseWriter, r *http.Request) {
@@ -137,22 +302,50 @@ func (a *App) Run() error {
 	handlerWithCORS := withCORS(a.httpServer)
 
 	// Start HTTP server in a separate goroutine
+	a.httpSrv = &http.Server{Handler: handlerWithCORS}
 	go func() {
-		httpPort := ":8080"
-		fmt.Printf("HTTP server starting on port %s \n", httpPort)
-		if err := http.ListenAndServe(httpPort, handlerWithCORS); err != nil {
+		fmt.Printf("HTTP server listening on %s \n", a.HTTPAddr())
+		if err := a.httpSrv.Serve(a.HTTP); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start HTTP server: %v", err)
 		}
 	}()
 
+	// apply config file changes until the context is done
+	go func() {
+		if err := a.reloader.Watch(a.ctx); err != nil {
+			slog.Error("Config watcher stopped", "error", err)
+		}
+	}()
+
 	<-a.ctx.Done()
 
 	return nil
 }
 
 func (a *App) Shutdown() error {
-	if err := a.tp.TracerProvider.Shutdown(context.Background()); err != nil {
-		slog.Error("Error shutting down tracer provider", "error", err)
+	a.grpcServer.GracefulStop()
+	if err := a.CloseHTTP(context.Background(), a.httpSrv); err != nil {
+		slog.Error("Error shutting down HTTP server", "error", err)
+	}
+	if err := a.grpcClient.Close(); err != nil {
+		slog.Error("Error closing product grpc client", "error", err)
+	}
+	if a.dbCluster != nil {
+		if err := a.dbCluster.Close(); err != nil {
+			slog.Error("Error closing database replicas", "error", err)
+		}
+	}
+
+	// providers passed in through options belong to the caller
+	if a.tp != nil {
+		if err := a.tp.TracerProvider.Shutdown(context.Background()); err != nil {
+			slog.Error("Error shutting down tracer provider", "error", err)
+		}
+	}
+	if a.meter != nil {
+		if err := a.meter.Shutdown(context.Background()); err != nil {
+			slog.Error("Error shutting down meter provider", "error", err)
+		}
 	}
 	return nil
 }
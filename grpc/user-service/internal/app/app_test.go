@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/repository"
+	"user-service/pb"
+
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/idempotency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		App:  config.AppConfig{Name: "user-service"},
+		HTTP: config.HTTPConfig{Host: "127.0.0.1"},
+		GRPC: config.GRPCConfig{Host: "127.0.0.1"},
+		Clients: config.ClientsConfig{
+			ProductService: grpcclient.Config{Target: "127.0.0.1", Port: 1},
+		},
+		Outbox:      config.OutboxConfig{PollInterval: time.Second, BatchSize: 10},
+		Idempotency: config.IdempotencyConfig{TTL: time.Hour},
+	}
+}
+
+func newTestApp(ctx context.Context, cfg *config.Config) (*App, error) {
+	users := repository.NewMemoryUserRepository()
+	return NewApp(ctx,
+		WithConfig(cfg),
+		WithTracerProvider(noop.NewTracerProvider()), WithMeterProvider(metricnoop.NewMeterProvider()),
+		WithRepositories(users, users, idempotency.NewMemoryStore()),
+	)
+}
+
+func TestNewApp_RejectsMissingRepositories(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	_, err := NewApp(context.Background(),
+		WithConfig(testConfig()),
+		WithTracerProvider(noop.NewTracerProvider()), WithMeterProvider(metricnoop.NewMeterProvider()),
+		WithLogOutput(io.Discard),
+		WithRepositories(users, nil, idempotency.NewMemoryStore()),
+	)
+	assert.ErrorContains(t, err, "outbox repository")
+}
+
+func TestApp_ServesGRPCAndSwagger(t *testing.T) {
+	t.Chdir(t.TempDir()) // the logger writes logs/app.log
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := newTestApp(ctx, testConfig())
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		assert.NoError(t, a.Shutdown())
+	})
+
+	conn, err := grpc.NewClient(a.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := pb.NewUserServiceClient(conn).GetUserByEmail(ctx, &pb.GetUserByEmailRequest{})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	// the swagger file is missing from the temp dir, but the handler answers
+	res, err := http.Get("http://" + a.HTTPAddr() + "/user/swagger.json")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Contains(t, string(body), "Swagger file not found")
+}
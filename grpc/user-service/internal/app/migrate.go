@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+	"user-service/internal/config"
+	"user-service/migrations"
+
+	"common-service/pkg/db"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "usage: migrate up | down | status | redo | create [-dir migrations] NAME"
+
+// Migrate runs the migrate subcommand against the configured database:
+// up, down, status, redo, or create to add a migration to the sources.
+func Migrate(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "create":
+		return createMigration(out, args)
+	case "up", "down", "status", "redo":
+		if len(args) > 0 {
+			return errors.New(migrateUsage)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.InitDB(ctx, config.GetDatabaseConfig(cfg), config.GetDatabaseDSN(cfg))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := db.NewMigrator(conn, "postgres", migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		results, err := migrator.Up(ctx)
+		printResults(out, results...)
+		if err == nil && len(results) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		result, err := migrator.Down(ctx)
+		if result != nil {
+			printResults(out, result)
+		}
+		return err
+	case "redo":
+		results, err := migrator.Redo(ctx)
+		printResults(out, results...)
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(out, statuses)
+		return nil
+	}
+}
+
+func createMigration(out io.Writer, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dir := flags.String("dir", "migrations", "directory holding the migration sources")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	return db.CreateMigration(*dir, flags.Arg(0))
+}
+
+func printResults(out io.Writer, results ...*goose.MigrationResult) {
+	for _, r := range results {
+		fmt.Fprintln(out, r)
+	}
+}
+
+func printStatus(out io.Writer, statuses []*goose.MigrationStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tAPPLIED AT\tMIGRATION")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.State, appliedAt, s.Source.Path)
+	}
+	w.Flush()
+}
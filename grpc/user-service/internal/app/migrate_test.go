@@ -0,0 +1,41 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"user-service/migrations"
+
+	"common-service/pkg/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	conn, err := sql.Open("postgres", "host=localhost")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = db.NewMigrator(conn, "postgres", migrations.FS)
+	assert.NoError(t, err)
+}
+
+func TestMigrate_Create(t *testing.T) {
+	dir := t.TempDir()
+
+	err := Migrate(context.Background(), &bytes.Buffer{}, []string{"create", "-dir", dir, "add_phone"})
+	require.NoError(t, err)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*_add_phone.sql"))
+	assert.Len(t, files, 1)
+}
+
+func TestMigrate_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "extra"}, {"create"}} {
+		err := Migrate(context.Background(), &bytes.Buffer{}, args)
+		assert.Error(t, err, "args %q", args)
+	}
+}
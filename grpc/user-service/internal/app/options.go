@@ -0,0 +1,88 @@
+package app
+
+import (
+	"io"
+	"net"
+	"user-service/internal/config"
+	"user-service/internal/domain"
+
+	"common-service/pkg/db"
+	"common-service/pkg/idempotency"
+
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Option changes how NewApp wires the service. Without options the service
+// runs as deployed; the options let tests run it in process.
+type Option func(*options)
+
+type options struct {
+	config             *config.Config
+	tracerProvider     oteltrace.TracerProvider
+	meterProvider      metric.MeterProvider
+	grpcListener       net.Listener
+	httpListener       net.Listener
+	logOutput          io.Writer
+	productDialOptions []grpc.DialOption
+	migrationMode      db.MigrationMode
+
+	userRepository   domain.UserRepository
+	outboxRepository domain.OutboxRepository
+	idempotencyStore idempotency.Store
+}
+
+// WithConfig uses cfg as is instead of loading and validating the config
+// files.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) { o.config = cfg }
+}
+
+// WithTracerProvider instruments the service with tp instead of setting up
+// an OTLP exporter. The caller owns tp.
+func WithTracerProvider(tp oteltrace.TracerProvider) Option {
+	return func(o *options) { o.tracerProvider = tp }
+}
+
+// WithMeterProvider records the service metrics with mp instead of setting
+// up an OTLP exporter. The caller owns mp.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) { o.meterProvider = mp }
+}
+
+// WithLogOutput sends the service logs to w instead of stdout and
+// logs/app.log.
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) { o.logOutput = w }
+}
+
+func WithGRPCListener(lis net.Listener) Option {
+	return func(o *options) { o.grpcListener = lis }
+}
+
+func WithHTTPListener(lis net.Listener) Option {
+	return func(o *options) { o.httpListener = lis }
+}
+
+// WithProductDialOptions adds dial options to the product service client,
+// e.g. a bufconn dialer.
+func WithProductDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.productDialOptions = append(o.productDialOptions, opts...) }
+}
+
+// WithMigrationMode overrides database.migrations.mode, e.g. from a
+// command line flag.
+func WithMigrationMode(mode db.MigrationMode) Option {
+	return func(o *options) { o.migrationMode = mode }
+}
+
+// WithRepositories replaces the Postgres backed repositories and idempotency
+// store; no database connection is opened. All three are required.
+func WithRepositories(users domain.UserRepository, outbox domain.OutboxRepository, store idempotency.Store) Option {
+	return func(o *options) {
+		o.userRepository = users
+		o.outboxRepository = outbox
+		o.idempotencyStore = store
+	}
+}
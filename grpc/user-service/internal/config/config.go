@@ -1,98 +1,185 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"strings"
-
-	"github.com/spf13/viper"
+	"log/slog"
+	"time"
+
+	pkgconfig "common-service/pkg/config"
+	"common-service/pkg/db"
+	"common-service/pkg/grpcclient"
+	"common-service/pkg/metrics"
+	"common-service/pkg/ping"
+	"common-service/pkg/server"
+	"common-service/pkg/trace"
 )
 
+// EnvPrefix namespaces environment overrides, e.g. USER_SERVICE_HTTP_PORT.
+const EnvPrefix = "USER_SERVICE"
+
 type Config struct {
-	App     AppConfig     `mapstructure:"app"`
-	HTTP    HTTPConfig    `mapstructure:"http"`
-	GRPC    GRPCConfig    `mapstructure:"grpc"`
-	DB      Database      `mapstructure:"database"`
-	Clients ClientsConfig `mapstructure:"clients"`
+	App         AppConfig         `mapstructure:"app"`
+	HTTP        HTTPConfig        `mapstructure:"http"`
+	GRPC        GRPCConfig        `mapstructure:"grpc"`
+	DB          Database          `mapstructure:"database"`
+	Clients     ClientsConfig     `mapstructure:"clients"`
+	Outbox      OutboxConfig      `mapstructure:"outbox"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Secrets     SecretsConfig     `mapstructure:"secrets"`
+
+	source pkgconfig.Source
 }
 
 type AppConfig struct {
-	Name     string      `mapstructure:"name"`
-	LogLevel string      `mapstructure:"log_level"`
-	Trace    TraceConfig `mapstructure:"trace"`
+	Name     string        `mapstructure:"name" validate:"required"`
+	LogLevel string        `mapstructure:"log_level" validate:"omitempty,oneof=debug info warn error"`
+	Trace    TraceConfig   `mapstructure:"trace"`
+	Metrics  MetricsConfig `mapstructure:"metrics"`
 }
 
-type TraceConfig struct {
-	Endpoint string `mapstructure:"endpoint"`
-}
+type TraceConfig = trace.Config
 
-type HTTPConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-}
+type MetricsConfig = metrics.Config
 
-type GRPCConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-}
+type (
+	HTTPConfig = server.Config
+	GRPCConfig = server.Config
+)
 
 type Database struct {
-	Postgres PostgresDBConfig `mapstructure:"postgres"`
+	Postgres   PostgresDBConfig `mapstructure:"postgres"`
+	Migrations MigrationsConfig `mapstructure:"migrations"`
+}
+
+type MigrationsConfig struct {
+	// Mode is what startup does about pending migrations: apply them
+	// (migrate), refuse to start (verify) or ignore them (skip).
+	Mode db.MigrationMode `mapstructure:"mode" validate:"omitempty,oneof=migrate verify skip"`
 }
 
 type PostgresDBConfig struct {
-	Name     string `mapstructure:"name"`
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Username string `mapstructure:"username"`
+	Name     string `mapstructure:"name" validate:"required"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"min=1,max=65535"`
+	Username string `mapstructure:"username" validate:"required"`
 	Password string `mapstructure:"password"`
 	SSLMode  bool   `mapstructure:"ssl_mode"`
-}
 
-type ClientsConfig struct {
-	ProductService ProductServiceConfig `mapstructure:"product_service"`
+	MaxOpenConns    int           `mapstructure:"max_open_conns" validate:"omitempty,min=1"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	Ping            ping.Config   `mapstructure:"ping"`
+
+	// Replicas serve reads that tolerate replication lag. They share the
+	// name, credentials and pool settings of the primary.
+	Replicas    []PostgresReplicaConfig `mapstructure:"replicas"`
+	Replication db.ReplicaConfig        `mapstructure:"replication"`
 }
 
-type ProductServiceConfig struct {
-	Target string `mapstructure:"target"`
-	Port   int    `mapstructure:"port"`
+type PostgresReplicaConfig struct {
+	Name string `mapstructure:"name" validate:"required"`
+	Host string `mapstructure:"host" validate:"required"`
+	Port int    `mapstructure:"port" validate:"min=1,max=65535"`
 }
 
-func Load() (*Config, error) {
-	viper.SetConfigName("dev")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
+type ClientsConfig struct {
+	ProductService grpcclient.Config `mapstructure:"product_service"`
+}
 
-	// allow overriding via env vars like APP_NAME, HTTP_PORT
-	viper.AutomaticEnv()
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"omitempty,min=10ms"`
+	BatchSize    int           `mapstructure:"batch_size" validate:"omitempty,min=1,max=10000"`
+	// MaxAttempts is how often an event is published before it is
+	// dead-lettered. Failed attempts back off from MinBackoff to MaxBackoff.
+	MaxAttempts int           `mapstructure:"max_attempts" validate:"omitempty,min=1"`
+	MinBackoff  time.Duration `mapstructure:"min_backoff" validate:"omitempty,min=10ms"`
+	MaxBackoff  time.Duration `mapstructure:"max_backoff" validate:"omitempty,min=10ms"`
+}
 
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl" validate:"omitempty,min=1s"`
+	// Lease is how long an unfinished call holds its key before another
+	// call may take it over.
+	Lease time.Duration `mapstructure:"lease" validate:"omitempty,min=1s"`
+	// CleanupInterval is how often expired keys are deleted from the store.
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval" validate:"omitempty,min=1s"`
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %v", err)
-	}
+type SecretsConfig struct {
+	// RefreshInterval is how often secret references such as
+	// file:///run/secrets/pg_password are resolved again. Zero disables it.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" validate:"omitempty,min=1s"`
+}
 
+// Load reads configs/base.yaml, the APP_ENV profile and local overrides,
+// applies USER_SERVICE_* env vars and validates the result.
+func Load() (*Config, error) {
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
+	src, err := pkgconfig.Load(&cfg, pkgconfig.Options{EnvPrefix: EnvPrefix})
+	if err != nil {
+		return nil, err
 	}
+	cfg.source = src
 
 	return &cfg, nil
 }
 
+// NewReloader returns a reloader starting from c that loads the config
+// again when its files change, see pkgconfig.Reloader.
+func (c *Config) NewReloader() *pkgconfig.Reloader[Config] {
+	return pkgconfig.NewReloader(c, c.source, Load)
+}
+
+// LogEffective logs the config with secrets redacted.
+func (c *Config) LogEffective(logger *slog.Logger) {
+	pkgconfig.LogEffective(logger, c.source, c)
+}
+
+// WatchSecrets calls fn with the key and new value of every secret
+// reference that resolves to something else than before, until ctx is done.
+func (c *Config) WatchSecrets(ctx context.Context, fn func(key, value string)) {
+	c.source.WatchSecrets(ctx, c.Secrets.RefreshInterval, fn)
+}
+
+// GetDatabaseConfig returns the pool settings of the Postgres database.
+func GetDatabaseConfig(cfg *Config) db.Config {
+	pg := cfg.DB.Postgres
+	return db.Config{
+		Driver:          "postgres",
+		Name:            pg.Name,
+		MaxOpenConns:    pg.MaxOpenConns,
+		MaxIdleConns:    pg.MaxIdleConns,
+		ConnMaxLifetime: pg.ConnMaxLifetime,
+		ConnMaxIdleTime: pg.ConnMaxIdleTime,
+		Ping:            pg.Ping,
+	}
+}
+
 func GetDatabaseDSN(cfg *Config) string {
+	return postgresDSN(cfg.DB.Postgres, cfg.DB.Postgres.Host, cfg.DB.Postgres.Port)
+}
+
+// GetReplicaDSN returns the DSN of replica r, with the credentials of the
+// primary.
+func GetReplicaDSN(cfg *Config, r PostgresReplicaConfig) string {
+	return postgresDSN(cfg.DB.Postgres, r.Host, r.Port)
+}
+
+func postgresDSN(pg PostgresDBConfig, host string, port int) string {
 	sslMode := "disable"
 
-	if cfg.DB.Postgres.SSLMode {
+	if pg.SSLMode {
 		sslMode = "require"
 	}
 
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DB.Postgres.Host,
-		cfg.DB.Postgres.Port,
-		cfg.DB.Postgres.Username,
-		cfg.DB.Postgres.Password,
-		cfg.DB.Postgres.Name,
+		host,
+		port,
+		pg.Username,
+		pg.Password,
+		pg.Name,
 		sslMode,
 	)
 }
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_Profiles(t *testing.T) {
+	t.Chdir("../..")
+
+	tests := []struct {
+		env        string
+		traceEP    string
+		dbHost     string
+		productTgt string
+	}{
+		{env: "dev", traceEP: "localhost:4318", dbHost: "localhost", productTgt: "localhost"},
+		{env: "prod", traceEP: "jaeger:4318", dbHost: "postgres", productTgt: "product-service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.env)
+			t.Setenv("USER_SERVICE_DATABASE_POSTGRES_PASSWORD", "env:TEST_PG_PASSWORD")
+			t.Setenv("TEST_PG_PASSWORD", "s3cret")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.App.Name != "user-service" {
+				t.Errorf("app.name = %q", cfg.App.Name)
+			}
+			if cfg.App.Trace.Endpoint != tt.traceEP {
+				t.Errorf("app.trace.endpoint = %q, want %q", cfg.App.Trace.Endpoint, tt.traceEP)
+			}
+			if cfg.DB.Postgres.Host != tt.dbHost {
+				t.Errorf("database.postgres.host = %q, want %q", cfg.DB.Postgres.Host, tt.dbHost)
+			}
+			if cfg.DB.Postgres.Password != "s3cret" {
+				t.Errorf("database.postgres.password = %q, want it resolved", cfg.DB.Postgres.Password)
+			}
+			if pool := GetDatabaseConfig(cfg); pool.MaxOpenConns != 25 || pool.ConnMaxLifetime != 30*time.Minute || pool.Ping.Attempts != 10 {
+				t.Errorf("pool = %+v", pool)
+			}
+			if cfg.Clients.ProductService.Target != tt.productTgt {
+				t.Errorf("clients.product_service.target = %q, want %q", cfg.Clients.ProductService.Target, tt.productTgt)
+			}
+		})
+	}
+}
+
+func TestLoad_EnvOverride(t *testing.T) {
+	t.Chdir("../..")
+	t.Setenv("USER_SERVICE_DATABASE_POSTGRES_HOST", "db.internal")
+	t.Setenv("USER_SERVICE_CLIENTS_PRODUCT_SERVICE_PORT", "0")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DB.Postgres.Host != "db.internal" {
+		t.Errorf("database.postgres.host = %q, want db.internal", cfg.DB.Postgres.Host)
+	}
+	if cfg.Clients.ProductService.Port != 0 {
+		t.Errorf("clients.product_service.port = %d, want 0", cfg.Clients.ProductService.Port)
+	}
+}
+
+func TestLoad_InvalidPort(t *testing.T) {
+	t.Chdir("../..")
+	t.Setenv("USER_SERVICE_GRPC_PORT", "70000")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestLoad_ProdNeedsPasswordSecret(t *testing.T) {
+	t.Chdir("../..")
+	t.Setenv("APP_ENV", "prod")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "database.postgres.password: resolve secret file:///run/secrets/pg_password") {
+		t.Fatalf("err = %v, want the missing secret file reported", err)
+	}
+}
+
+func TestGetReplicaDSN(t *testing.T) {
+	cfg := &Config{DB: Database{Postgres: PostgresDBConfig{
+		Name:     "users",
+		Host:     "primary",
+		Port:     5432,
+		Username: "app",
+		Password: "pw",
+	}}}
+
+	got := GetReplicaDSN(cfg, PostgresReplicaConfig{Name: "r1", Host: "replica-1", Port: 5433})
+	want := "host=replica-1 port=5433 user=app password=pw dbname=users sslmode=disable"
+	if got != want {
+		t.Errorf("GetReplicaDSN = %q, want %q", got, want)
+	}
+}
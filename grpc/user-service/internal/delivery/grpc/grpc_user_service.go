@@ -4,6 +4,9 @@ import (
 	"context"
 	"user-service/internal/domain"
 	"user-service/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserService struct {
@@ -26,3 +29,26 @@ func (s *UserService) CreateUser(ctx context.Context, req *pb.RegisterRequest) (
 func (s *UserService) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.ApiResponse, error) {
 	return &pb.ApiResponse{Success: true, Message: "User fetched successfully"}, nil
 }
+
+// ListUsers returns the newest users. Paging by offset is not supported.
+func (s *UserService) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if req.GetOffset() != 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset is not supported")
+	}
+
+	users, limit, err := s.userUsecase.ListUsers(ctx, int(req.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListUsersResponse{
+		Success: true,
+		Message: "Users fetched successfully",
+		Users:   make([]*pb.User, len(users)),
+		Limit:   int32(limit),
+	}
+	for i, u := range users {
+		resp.Users[i] = &pb.User{Id: u.ID, Email: u.Email}
+	}
+	return resp, nil
+}
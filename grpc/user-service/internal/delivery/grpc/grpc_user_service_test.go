@@ -0,0 +1,101 @@
+package grpcservices
+
+import (
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"testing"
+	"user-service/internal/domain"
+	"user-service/pb"
+
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeUserUsecase struct {
+	err   error
+	users []domain.User
+	limit int
+}
+
+func (u *fakeUserUsecase) CreateUser(ctx context.Context) error {
+	_, span := trace.StartSpan(ctx, "UserUsecase.CreateUser")
+	defer span.End()
+
+	return u.err
+}
+
+func (u *fakeUserUsecase) ListUsers(ctx context.Context, limit int) ([]domain.User, int, error) {
+	_, span := trace.StartSpan(ctx, "UserUsecase.ListUsers")
+	defer span.End()
+
+	u.limit = limit
+	return u.users, domain.MaxListUsersLimit, u.err
+}
+
+func newUserClient(t *testing.T, rec *tracetest.Recorder, uc *fakeUserUsecase) pb.UserServiceClient {
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		pb.RegisterUserServiceServer(s, NewUserService(uc))
+	})
+	return pb.NewUserServiceClient(conn)
+}
+
+func TestUserService_CreateUser(t *testing.T) {
+	rec := tracetest.Install(t)
+	client := newUserClient(t, rec, &fakeUserUsecase{})
+
+	if _, err := client.CreateUser(context.Background(), &pb.RegisterRequest{Username: "test"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	rec.ServerSpan(t, "user.UserService/CreateUser").
+		HasParent("user.UserService/CreateUser").
+		HasChild("UserUsecase.CreateUser").
+		HasStatus(codes.Unset)
+}
+
+func TestUserService_CreateUser_UsecaseError(t *testing.T) {
+	rec := tracetest.Install(t)
+	client := newUserClient(t, rec, &fakeUserUsecase{err: errors.New("insert failed")})
+
+	if _, err := client.CreateUser(context.Background(), &pb.RegisterRequest{Username: "test"}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	rec.ServerSpan(t, "user.UserService/CreateUser").
+		HasChild("UserUsecase.CreateUser").
+		HasStatus(codes.Error)
+	rec.ClientSpan(t, "user.UserService/CreateUser").
+		HasStatus(codes.Error)
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	rec := tracetest.Install(t)
+	uc := &fakeUserUsecase{users: []domain.User{{ID: "2", Email: "b@example.com"}, {ID: "1", Email: "a@example.com"}}}
+	client := newUserClient(t, rec, uc)
+
+	resp, err := client.ListUsers(context.Background(), &pb.ListUsersRequest{Limit: 5})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if uc.limit != 5 {
+		t.Errorf("limit = %d, want 5", uc.limit)
+	}
+	if resp.GetLimit() != domain.MaxListUsersLimit {
+		t.Errorf("response limit = %d, want the one the usecase applied", resp.GetLimit())
+	}
+	if len(resp.GetUsers()) != 2 || resp.GetUsers()[0].GetEmail() != "b@example.com" {
+		t.Errorf("users = %v", resp.GetUsers())
+	}
+
+	rec.ServerSpan(t, "user.UserService/ListUsers").
+		HasChild("UserUsecase.ListUsers")
+
+	_, err = client.ListUsers(context.Background(), &pb.ListUsersRequest{Offset: 10})
+	if status.Code(err) != grpccodes.InvalidArgument {
+		t.Errorf("err = %v, want InvalidArgument for an offset", err)
+	}
+}
@@ -1,5 +1,11 @@
 package domain
 
+const (
+	// DefaultListUsersLimit and MaxListUsersLimit bound a ListUsers page.
+	DefaultListUsersLimit = 20
+	MaxListUsersLimit     = 100
+)
+
 type User struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	UserAggregate = "user"
+
+	UserCreated = "UserCreated"
+	UserUpdated = "UserUpdated"
+	UserDeleted = "UserDeleted"
+)
+
+// OutboxEvent is a domain event stored in the outbox table in the same
+// transaction as the change that produced it.
+type OutboxEvent struct {
+	ID            string            `json:"id"`
+	AggregateType string            `json:"aggregate_type"`
+	AggregateID   string            `json:"aggregate_id"`
+	EventType     string            `json:"event_type"`
+	Payload       json.RawMessage   `json:"payload"`
+	Headers       map[string]string `json:"headers"`
+	Attempts      int               `json:"attempts"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// NewUserEvent builds an outbox event for a change on the given user.
+// The password is never part of the payload.
+func NewUserEvent(eventType string, user *User) (*OutboxEvent, error) {
+	payload, err := json.Marshal(struct {
+		ID       string `json:"id"`
+		Username string `json:"username,omitempty"`
+		Email    string `json:"email"`
+	}{user.ID, user.Username, user.Email})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		AggregateType: UserAggregate,
+		AggregateID:   user.ID,
+		EventType:     eventType,
+		Payload:       payload,
+		Headers:       map[string]string{},
+	}, nil
+}
+
+// EventPublisher delivers outbox events to the outside world.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *OutboxEvent) error
+}
+
+// OutboxRetryPolicy decides when a failed event is published again and when
+// it is given up on.
+type OutboxRetryPolicy struct {
+	// MaxAttempts is the number of publish attempts before an event is
+	// dead-lettered. Zero retries forever.
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Exhausted reports whether an event that failed attempts times is
+// dead-lettered.
+func (p OutboxRetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// Backoff returns how long to wait after the given failed attempt. The delay
+// doubles from MinBackoff up to MaxBackoff.
+func (p OutboxRetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.MinBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+type OutboxRepository interface {
+	// ProcessPending locks up to limit events that are due, hands each one to
+	// fn and marks it published when fn succeeds. A failed event is retried
+	// after policy.Backoff, or dead-lettered and never handed out again once
+	// policy.Exhausted. It returns the number of events published.
+	ProcessPending(ctx context.Context, limit int, policy OutboxRetryPolicy, fn func(ctx context.Context, event *OutboxEvent) error) (int, error)
+}
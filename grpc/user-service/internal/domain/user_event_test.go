@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRetryPolicy(t *testing.T) {
+	policy := OutboxRetryPolicy{MaxAttempts: 5, MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 100: 5 * time.Second} {
+		assert.Equal(t, want, policy.Backoff(attempts), "attempt %d", attempts)
+	}
+
+	assert.False(t, policy.Exhausted(4))
+	assert.True(t, policy.Exhausted(5))
+	assert.False(t, OutboxRetryPolicy{}.Exhausted(100), "zero MaxAttempts retries forever")
+}
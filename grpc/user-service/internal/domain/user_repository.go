@@ -4,4 +4,5 @@ import "context"
 
 type UserRepository interface {
 	CreateUser(ctx context.Context) error
+	ListUsers(ctx context.Context, limit int) ([]User, error)
 }
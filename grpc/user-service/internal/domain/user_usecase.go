@@ -4,4 +4,8 @@ import "context"
 
 type UserUsecase interface {
 	CreateUser(ctx context.Context) error
+	// ListUsers returns up to limit users, newest first, and the limit it
+	// applied. A limit outside 1..MaxListUsersLimit is clamped to it, zero
+	// meaning the default.
+	ListUsers(ctx context.Context, limit int) (users []User, applied int, err error)
 }
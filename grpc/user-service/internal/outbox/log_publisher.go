@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"user-service/internal/domain"
+)
+
+// logPublisher writes events to the application log. It is the default
+// publisher until a message broker is configured.
+type logPublisher struct{}
+
+func NewLogPublisher() *logPublisher {
+	return &logPublisher{}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	slog.InfoContext(ctx, "Outbox event published",
+		"id", event.ID,
+		"aggregate_type", event.AggregateType,
+		"aggregate_id", event.AggregateID,
+		"event_type", event.EventType,
+		"payload", string(event.Payload),
+		"headers", event.Headers,
+	)
+	return nil
+}
@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"common-service/pkg/trace"
+	"context"
+	"log/slog"
+	"time"
+	"user-service/internal/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+)
+
+// Relay polls the outbox table and hands unpublished events to an
+// EventPublisher.
+type Relay struct {
+	repository   domain.OutboxRepository
+	publisher    domain.EventPublisher
+	pollInterval time.Duration
+	batchSize    int
+	retry        domain.OutboxRetryPolicy
+}
+
+func NewRelay(repository domain.OutboxRepository, publisher domain.EventPublisher, pollInterval time.Duration, batchSize int, retry domain.OutboxRetryPolicy) *Relay {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultMaxAttempts
+	}
+	if retry.MinBackoff <= 0 {
+		retry.MinBackoff = defaultMinBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Relay{
+		repository:   repository,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		retry:        retry,
+	}
+}
+
+// Run publishes pending events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes full batches of due events until a batch comes back short.
+func (r *Relay) drain(ctx context.Context) {
+	for {
+		n, err := r.repository.ProcessPending(ctx, r.batchSize, r.retry, r.publish)
+		if err != nil {
+			slog.Error("Failed to relay outbox events", "error", err)
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+// publish restores the trace context captured when the event was written so
+// the async hop shows up under the originating request, then re-injects the
+// producer span context into the headers for consumers.
+func (r *Relay) publish(ctx context.Context, event *domain.OutboxEvent) (err error) {
+	parent := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(event.Headers))
+
+	ctx, span := trace.StartSpan(parent, "OutboxRelay.Publish "+event.EventType,
+		trace.WithKind(oteltrace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.operation.type", "publish"),
+			attribute.String("messaging.destination.name", event.AggregateType),
+			attribute.String("messaging.message.id", event.ID),
+			attribute.String("outbox.event_type", event.EventType),
+			attribute.Int("outbox.attempts", event.Attempts),
+		),
+	)
+	defer func() { trace.End(span, err) }()
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(event.Headers))
+
+	return r.publisher.Publish(ctx, event)
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"user-service/internal/domain"
+	"user-service/internal/mocks"
+	"user-service/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRelay_PublishesPendingEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUserRepository()
+	for range 3 {
+		require.NoError(t, repo.CreateUser(ctx))
+	}
+
+	publisher := mocks.NewMockEventPublisher(t)
+	publisher.EXPECT().Publish(mock.Anything, mock.Anything).Return(nil).Times(3)
+
+	// a batch of 2 is drained in two rounds
+	relay := NewRelay(repo, publisher, time.Second, 2, domain.OutboxRetryPolicy{})
+	relay.drain(ctx)
+
+	// published events are not handed out again
+	relay.drain(ctx)
+}
+
+func TestRelay_RetriesFailedEventsWithoutBlockingOthers(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUserRepository()
+	require.NoError(t, repo.CreateUser(ctx))
+	require.NoError(t, repo.CreateUser(ctx))
+
+	attempts := map[string]int{}
+	publisher := mocks.NewMockEventPublisher(t)
+	publisher.EXPECT().Publish(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, event *domain.OutboxEvent) error {
+		attempts[event.AggregateID]++
+		if event.AggregateID == "1" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+
+	relay := NewRelay(repo, publisher, time.Second, 10, domain.OutboxRetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  20 * time.Millisecond,
+		MaxBackoff:  20 * time.Millisecond,
+	})
+	relay.drain(ctx)
+	assert.Equal(t, map[string]int{"1": 1, "2": 1}, attempts, "the failing event does not hold up the next one")
+
+	relay.drain(ctx)
+	assert.Equal(t, 1, attempts["1"], "retried before its backoff")
+
+	for range 3 {
+		time.Sleep(30 * time.Millisecond)
+		relay.drain(ctx)
+	}
+	assert.Equal(t, map[string]int{"1": 3, "2": 1}, attempts, "dead-lettered after MaxAttempts")
+}
+
+func TestRelay_ContinuesTheOriginatingTrace(t *testing.T) {
+	rec := tracetest.Install(t)
+	repo := repository.NewMemoryUserRepository()
+
+	ctx, span := trace.StartSpan(context.Background(), "UserUsecase.CreateUser")
+	require.NoError(t, repo.CreateUser(ctx))
+	span.End()
+
+	var headers map[string]string
+	publisher := mocks.NewMockEventPublisher(t)
+	publisher.EXPECT().Publish(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, event *domain.OutboxEvent) error {
+		headers = event.Headers
+		return nil
+	})
+
+	// the relay runs detached from the request
+	NewRelay(repo, publisher, time.Second, 10, domain.OutboxRetryPolicy{}).drain(context.Background())
+
+	published := rec.Span(t, "OutboxRelay.Publish UserCreated").
+		HasKind(oteltrace.SpanKindProducer).
+		HasParent("UserRepository.CreateUser").
+		HasAttribute("messaging.destination.name", domain.UserAggregate)
+	rec.SameTrace(t, "UserUsecase.CreateUser", "OutboxRelay.Publish UserCreated")
+
+	// consumers continue from the producer span
+	carried := oteltrace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(headers)))
+	assert.Equal(t, published.Span().SpanContext().SpanID(), carried.SpanID())
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"common-service/pkg/trace"
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+	"user-service/internal/domain"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// memoryUserRepository keeps users and their outbox events in memory. It
+// stands in for Postgres when the service runs in process, and implements
+// both domain.UserRepository and domain.OutboxRepository so events written by
+// CreateUser reach the relay.
+type memoryUserRepository struct {
+	mu     sync.Mutex
+	users  []*domain.User
+	events []*memoryOutboxEvent
+}
+
+type memoryOutboxEvent struct {
+	event        *domain.OutboxEvent
+	published    bool
+	deadLettered bool
+	nextAttempt  time.Time
+}
+
+func NewMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{}
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context) error {
+	ctx, span := trace.StartSpan(ctx, "UserRepository.CreateUser")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := &domain.User{ID: strconv.Itoa(len(r.users) + 1), Email: gofakeit.Email()}
+
+	event, err := domain.NewUserEvent(domain.UserCreated, user)
+	if err != nil {
+		return err
+	}
+	injectTraceHeaders(ctx, event)
+	event.ID = strconv.Itoa(len(r.events) + 1)
+	event.CreatedAt = time.Now()
+
+	r.users = append(r.users, user)
+	r.events = append(r.events, &memoryOutboxEvent{event: event})
+
+	return nil
+}
+
+func (r *memoryUserRepository) ListUsers(ctx context.Context, limit int) ([]domain.User, error) {
+	_, span := trace.StartSpan(ctx, "UserRepository.ListUsers")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit = max(limit, 0)
+	users := make([]domain.User, 0, min(limit, len(r.users)))
+	for i := len(r.users) - 1; i >= 0 && len(users) < limit; i-- {
+		users = append(users, *r.users[i])
+	}
+	return users, nil
+}
+
+// Users returns a copy of the stored users.
+func (r *memoryUserRepository) Users() []domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]domain.User, len(r.users))
+	for i, u := range r.users {
+		users[i] = *u
+	}
+	return users
+}
+
+func (r *memoryUserRepository) ProcessPending(ctx context.Context, limit int, policy domain.OutboxRetryPolicy, fn func(ctx context.Context, event *domain.OutboxEvent) error) (int, error) {
+	ctx, span := trace.StartSpan(ctx, "OutboxRepository.ProcessPending")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	published := 0
+	for _, e := range r.events {
+		if limit == 0 {
+			break
+		}
+		if e.published || e.deadLettered || e.nextAttempt.After(now) {
+			continue
+		}
+		limit--
+
+		err := fn(ctx, e.event)
+		e.event.Attempts++
+		if err == nil {
+			e.published = true
+			published++
+			continue
+		}
+
+		if policy.Exhausted(e.event.Attempts) {
+			slog.Error("Outbox event dead-lettered", "id", e.event.ID, "type", e.event.EventType, "attempts", e.event.Attempts, "error", err)
+			e.deadLettered = true
+			continue
+		}
+		backoff := policy.Backoff(e.event.Attempts)
+		slog.Error("Failed to publish outbox event", "id", e.event.ID, "type", e.event.EventType, "attempts", e.event.Attempts, "retry_in", backoff, "error", err)
+		e.nextAttempt = now.Add(backoff)
+	}
+
+	return published, nil
+}
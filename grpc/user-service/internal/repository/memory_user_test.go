@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUserRepository_ListUsers(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+	for range 3 {
+		require.NoError(t, repo.CreateUser(ctx))
+	}
+
+	users, err := repo.ListUsers(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "3", users[0].ID, "newest first")
+
+	users, err = repo.ListUsers(ctx, -1)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
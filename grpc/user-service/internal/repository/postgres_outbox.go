@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"common-service/pkg/db"
+	"common-service/pkg/trace"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"user-service/internal/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type outboxRepository struct {
+	db *sql.DB
+	tx *db.TxManager
+}
+
+func NewOutboxRepository(conn *sql.DB) *outboxRepository {
+	return &outboxRepository{
+		db: conn,
+		tx: db.NewTxManager(conn),
+	}
+}
+
+// insertOutboxEvent stores event inside the transaction of q so it is committed or rolled back
+// together with the change that produced it. The current trace context is
+// injected into the event headers so the relay can continue the trace.
+func insertOutboxEvent(ctx context.Context, q db.DBTX, event *domain.OutboxEvent) error {
+	injectTraceHeaders(ctx, event)
+
+	headers, err := json.Marshal(event.Headers)
+	if err != nil {
+		return err
+	}
+
+	return q.QueryRowContext(ctx,
+		"INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload, headers) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		event.AggregateType, event.AggregateID, event.EventType, []byte(event.Payload), headers,
+	).Scan(&event.ID, &event.CreatedAt)
+}
+
+func injectTraceHeaders(ctx context.Context, event *domain.OutboxEvent) {
+	if event.Headers == nil {
+		event.Headers = map[string]string{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(event.Headers))
+}
+
+func (r *outboxRepository) ProcessPending(ctx context.Context, limit int, policy domain.OutboxRetryPolicy, fn func(ctx context.Context, event *domain.OutboxEvent) error) (int, error) {
+	ctx, span := trace.StartSpan(ctx, "OutboxRepository.ProcessPending")
+	defer span.End()
+
+	// publishing cannot be undone, so a failed batch is left to the next
+	// poll instead of being retried here
+	var published int
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		published, err = r.processPending(ctx, db.Conn(ctx, r.db), limit, policy, fn)
+		return err
+	}, db.WithMaxRetries(0))
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// processPending publishes up to limit due events locked in tx.
+func (r *outboxRepository) processPending(ctx context.Context, tx db.DBTX, limit int, policy domain.OutboxRetryPolicy, fn func(ctx context.Context, event *domain.OutboxEvent) error) (int, error) {
+	// SKIP LOCKED lets several relays run side by side without publishing
+	// the same event twice. Failed events wait for next_attempt_at, so they
+	// do not hold up the ones behind them.
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, attempts, created_at FROM outbox_events WHERE published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED",
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []*domain.OutboxEvent
+	for rows.Next() {
+		var (
+			event   domain.OutboxEvent
+			payload []byte
+			headers []byte
+		)
+		if err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.EventType, &payload, &headers, &event.Attempts, &event.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		event.Payload = payload
+		if err := json.Unmarshal(headers, &event.Headers); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range events {
+		if err := fn(ctx, event); err != nil {
+			if err := r.fail(ctx, tx, event, policy, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL WHERE id = $1", event.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// fail records a failed publish of event and schedules the next attempt, or
+// dead-letters the event once policy gives up on it.
+func (r *outboxRepository) fail(ctx context.Context, tx db.DBTX, event *domain.OutboxEvent, policy domain.OutboxRetryPolicy, cause error) error {
+	attempts := event.Attempts + 1
+	if policy.Exhausted(attempts) {
+		slog.Error("Outbox event dead-lettered", "id", event.ID, "type", event.EventType, "attempts", attempts, "error", cause)
+		_, err := tx.ExecContext(ctx, "UPDATE outbox_events SET attempts = $2, last_error = $3, dead_lettered_at = CURRENT_TIMESTAMP WHERE id = $1", event.ID, attempts, cause.Error())
+		return err
+	}
+
+	backoff := policy.Backoff(attempts)
+	slog.Error("Failed to publish outbox event", "id", event.ID, "type", event.EventType, "attempts", attempts, "retry_in", backoff, "error", cause)
+	_, err := tx.ExecContext(ctx,
+		"UPDATE outbox_events SET attempts = $2, last_error = $3, next_attempt_at = CURRENT_TIMESTAMP + $4 * INTERVAL '1 millisecond' WHERE id = $1",
+		event.ID, attempts, cause.Error(), backoff.Milliseconds(),
+	)
+	return err
+}
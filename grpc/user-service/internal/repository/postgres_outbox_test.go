@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"common-service/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+	"user-service/internal/domain"
+	"user-service/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB connects to E2E_POSTGRES_DSN and migrates a fresh schema, skipping
+// the test when the variable is not set. The pool holds one connection so
+// the search_path applies to every query.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("E2E_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("E2E_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := db.InitDB(ctx, db.Config{Driver: "postgres", MaxOpenConns: 1}, dsn)
+	require.NoError(t, err)
+
+	schema := fmt.Sprintf("outbox_test_%d", time.Now().UnixNano())
+	_, err = conn.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = conn.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		_ = conn.Close()
+	})
+
+	_, err = conn.ExecContext(ctx, "SET search_path TO "+schema+", public")
+	require.NoError(t, err)
+	require.NoError(t, db.ApplyMigrations(ctx, conn, "postgres", migrations.FS))
+
+	return conn
+}
+
+func TestOutboxRepository_ProcessPending(t *testing.T) {
+	conn := testDB(t)
+	ctx := context.Background()
+	repo := NewOutboxRepository(conn)
+
+	for _, id := range []string{"poison", "ok"} {
+		event, err := domain.NewUserEvent(domain.UserCreated, &domain.User{ID: id})
+		require.NoError(t, err)
+		require.NoError(t, insertOutboxEvent(ctx, conn, event))
+	}
+
+	policy := domain.OutboxRetryPolicy{MaxAttempts: 2, MinBackoff: 50 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	var handed []string
+	publish := func(ctx context.Context, event *domain.OutboxEvent) error {
+		handed = append(handed, event.AggregateID)
+		if event.AggregateID == "poison" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+
+	published, err := repo.ProcessPending(ctx, 10, policy, publish)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.ElementsMatch(t, []string{"poison", "ok"}, handed)
+
+	// the failed event waits for its backoff
+	handed = nil
+	_, err = repo.ProcessPending(ctx, 10, policy, publish)
+	require.NoError(t, err)
+	assert.Empty(t, handed)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = repo.ProcessPending(ctx, 10, policy, publish)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"poison"}, handed)
+
+	var (
+		attempts  int
+		lastError string
+		dead      bool
+	)
+	require.NoError(t, conn.QueryRowContext(ctx,
+		"SELECT attempts, last_error, dead_lettered_at IS NOT NULL FROM outbox_events WHERE aggregate_id = 'poison'",
+	).Scan(&attempts, &lastError, &dead))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "broker unavailable", lastError)
+	assert.True(t, dead, "dead-lettered after MaxAttempts")
+
+	time.Sleep(100 * time.Millisecond)
+	handed = nil
+	_, err = repo.ProcessPending(ctx, 10, policy, publish)
+	require.NoError(t, err)
+	assert.Empty(t, handed, "dead-lettered events are not handed out again")
+}
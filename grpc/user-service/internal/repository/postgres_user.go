@@ -1,33 +1,82 @@
 package repository
 
 import (
+	"common-service/pkg/db"
 	"common-service/pkg/trace"
 	"context"
 	"database/sql"
 	"log/slog"
+	"user-service/internal/domain"
 
 	"github.com/brianvoe/gofakeit/v6"
 )
 
 type userRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	cluster *db.Cluster
+	tx      *db.TxManager
 }
 
-func NewUserRepository(db *sql.DB) *userRepository {
+// NewUserRepository writes to the primary of cluster and reads users from
+// its replicas where slightly stale data is fine.
+func NewUserRepository(cluster *db.Cluster) *userRepository {
 	return &userRepository{
-		db: db,
+		db:      cluster.Primary(),
+		cluster: cluster,
+		tx:      db.NewTxManager(cluster.Primary()),
 	}
 }
 
-func (r *userRepository) CreateUser(ctx context.Context) error {
+func (r *userRepository) CreateUser(ctx context.Context) (err error) {
 	ctx, span := trace.StartSpan(ctx, "UserRepository.CreateUser")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
-	_, err := r.db.ExecContext(ctx, "INSERT INTO users (email, password_hash, first_name, middle_name, last_name) VALUES ($1, $2, $3, $4, $5)", gofakeit.Email(), "password", "John", "Doe", "test")
+	// the user and its outbox event join the transaction of the caller, if any
+	return r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		q := db.Conn(ctx, r.db)
+
+		user := &domain.User{Email: gofakeit.Email()}
+		err := q.QueryRowContext(ctx, "INSERT INTO users (email, password_hash, first_name, middle_name, last_name) VALUES ($1, $2, $3, $4, $5) RETURNING id", user.Email, "password", "John", "Doe", "test").Scan(&user.ID)
+		if err != nil {
+			slog.Error("Failed to create user", "error", err)
+			return err
+		}
+
+		event, err := domain.NewUserEvent(domain.UserCreated, user)
+		if err != nil {
+			return err
+		}
+
+		if err := insertOutboxEvent(ctx, q, event); err != nil {
+			slog.Error("Failed to write outbox event", "error", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// ListUsers returns up to limit users, newest first. The list may lag
+// behind recent writes since it is read from a replica when one is healthy.
+func (r *userRepository) ListUsers(ctx context.Context, limit int) (users []domain.User, err error) {
+	ctx, span := trace.StartSpan(ctx, "UserRepository.ListUsers")
+	defer func() { trace.End(span, err) }()
+
+	rows, err := r.cluster.Reader(ctx, db.PreferReplica()).QueryContext(ctx,
+		"SELECT id, email FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1",
+		max(limit, 0),
+	)
 	if err != nil {
-		slog.Error("Failed to create user", "error", err)
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
 }
@@ -3,8 +3,11 @@ package usecase
 import (
 	"common-service/pkg/trace"
 	"context"
+	"product-service/pb"
 	"user-service/internal/domain"
-	"user-service/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type userUsecase struct {
@@ -19,21 +22,40 @@ func NewUserUsecase(productGrpcClient pb.ProductServiceClient, userRepository do
 	}
 }
 
-func (u *userUsecase) CreateUser(ctx context.Context) error {
+func (u *userUsecase) CreateUser(ctx context.Context) (err error) {
 	ctx, span := trace.StartSpan(ctx, "UserUsecase.CreateUser")
-	defer span.End()
+	defer func() { trace.End(span, err) }()
 
 	// create user
-	err := u.userRepository.CreateUser(ctx)
+	err = u.userRepository.CreateUser(ctx)
 	if err != nil {
 		return err
 	}
 
 	// call product service
 	_, err = u.productGrpcClient.GetProduct(ctx, &pb.GetProductRequest{Id: "test-id"})
+	if status.Code(err) == codes.ResourceExhausted {
+		// the user is stored, so the call must not look like it was refused
+		// before running; idempotency keys are released on ResourceExhausted
+		return status.Error(codes.Unavailable, status.Convert(err).Message())
+	}
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (u *userUsecase) ListUsers(ctx context.Context, limit int) (users []domain.User, applied int, err error) {
+	ctx, span := trace.StartSpan(ctx, "UserUsecase.ListUsers")
+	defer func() { trace.End(span, err) }()
+
+	switch {
+	case limit <= 0:
+		limit = domain.DefaultListUsersLimit
+	case limit > domain.MaxListUsersLimit:
+		limit = domain.MaxListUsersLimit
+	}
+	users, err = u.userRepository.ListUsers(ctx, limit)
+	return users, limit, err
+}
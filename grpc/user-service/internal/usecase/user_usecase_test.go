@@ -1,9 +1,154 @@
 package usecase
 
 import (
+	"common-service/pkg/trace"
+	"common-service/pkg/tracetest"
+	"context"
+	"errors"
+	"product-service/pb"
 	"testing"
+	"user-service/internal/domain"
+	"user-service/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// newTracedUserRepository returns a repository mock that records a span the
+// way the postgres repository does.
+func newTracedUserRepository(t *testing.T, err error) *mocks.MockUserRepository {
+	repo := mocks.NewMockUserRepository(t)
+	repo.EXPECT().CreateUser(mock.Anything).RunAndReturn(func(ctx context.Context) error {
+		_, span := trace.StartSpan(ctx, "UserRepository.CreateUser")
+		defer span.End()
+
+		return err
+	})
+	return repo
+}
+
+type fakeProductServer struct {
+	pb.UnimplementedProductServiceServer
+}
+
+func (s *fakeProductServer) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	return &pb.GetProductResponse{Product: &pb.Product{Id: req.GetId()}}, nil
+}
+
+func newProductClient(t *testing.T, rec *tracetest.Recorder) pb.ProductServiceClient {
+	conn := rec.Serve(t, func(s *grpc.Server) {
+		pb.RegisterProductServiceServer(s, &fakeProductServer{})
+	})
+	return pb.NewProductServiceClient(conn)
+}
+
+func TestUserUsecase_CreateUser_Table(t *testing.T) {
+	errInsert := errors.New("insert failed")
+	errUnavailable := errors.New("product service unavailable")
+
+	tests := []struct {
+		name    string
+		setup   func(repo *mocks.MockUserRepository, products *mocks.MockProductServiceClient)
+		wantErr error
+	}{
+		{
+			name: "creates user and fetches product",
+			setup: func(repo *mocks.MockUserRepository, products *mocks.MockProductServiceClient) {
+				repo.EXPECT().CreateUser(mock.Anything).Return(nil)
+				products.EXPECT().
+					GetProduct(mock.Anything, &pb.GetProductRequest{Id: "test-id"}).
+					Return(&pb.GetProductResponse{Product: &pb.Product{Id: "test-id"}}, nil)
+			},
+		},
+		{
+			name: "repository error skips product call",
+			setup: func(repo *mocks.MockUserRepository, products *mocks.MockProductServiceClient) {
+				repo.EXPECT().CreateUser(mock.Anything).Return(errInsert)
+			},
+			wantErr: errInsert,
+		},
+		{
+			name: "product service error",
+			setup: func(repo *mocks.MockUserRepository, products *mocks.MockProductServiceClient) {
+				repo.EXPECT().CreateUser(mock.Anything).Return(nil)
+				products.EXPECT().GetProduct(mock.Anything, mock.Anything).Return(nil, errUnavailable)
+			},
+			wantErr: errUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+			products := mocks.NewMockProductServiceClient(t)
+			tt.setup(repo, products)
+
+			err := NewUserUsecase(products, repo).CreateUser(context.Background())
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserUsecase_CreateUser_ProductRejectedAfterInsert(t *testing.T) {
+	repo := mocks.NewMockUserRepository(t)
+	repo.EXPECT().CreateUser(mock.Anything).Return(nil)
+	products := mocks.NewMockProductServiceClient(t)
+	products.EXPECT().GetProduct(mock.Anything, mock.Anything).Return(nil, status.Error(codes.ResourceExhausted, "bulkhead full"))
+
+	err := NewUserUsecase(products, repo).CreateUser(context.Background())
+	assert.Equal(t, codes.Unavailable, status.Code(err), "the stored user must not look like a refused call")
+}
+
 func TestUserUsecase_CreateUser(t *testing.T) {
+	rec := tracetest.Install(t)
+	uc := NewUserUsecase(newProductClient(t, rec), newTracedUserRepository(t, nil))
+
+	if err := uc.CreateUser(context.Background()); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	rec.Span(t, "UserUsecase.CreateUser").
+		IsRoot().
+		HasChild("UserRepository.CreateUser").
+		HasClientSpan("product.ProductService/GetProduct")
+	rec.ServerSpan(t, "product.ProductService/GetProduct").
+		HasParent("product.ProductService/GetProduct")
+}
+
+func TestUserUsecase_CreateUser_RepositoryError(t *testing.T) {
+	rec := tracetest.Install(t)
+	uc := NewUserUsecase(newProductClient(t, rec), newTracedUserRepository(t, errors.New("insert failed")))
+
+	if err := uc.CreateUser(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	rec.Span(t, "UserUsecase.CreateUser").
+		HasError().
+		HasChild("UserRepository.CreateUser").
+		HasNoChild("product.ProductService/GetProduct")
+}
+
+func TestUserUsecase_ListUsers_ClampsLimit(t *testing.T) {
+	tests := []struct {
+		limit, want int
+	}{
+		{limit: 0, want: domain.DefaultListUsersLimit},
+		{limit: -1, want: domain.DefaultListUsersLimit},
+		{limit: 5, want: 5},
+		{limit: 1000, want: domain.MaxListUsersLimit},
+	}
+
+	for _, tt := range tests {
+		repo := mocks.NewMockUserRepository(t)
+		repo.EXPECT().ListUsers(mock.Anything, tt.want).Return([]domain.User{{ID: "1"}}, nil)
 
+		users, applied, err := NewUserUsecase(nil, repo).ListUsers(context.Background(), tt.limit)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, tt.want, applied)
+	}
 }
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMPTZ DEFAULT NULL;
+
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due
+    ON outbox_events (next_attempt_at)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_events_due;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished
+    ON outbox_events (created_at)
+    WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS dead_lettered_at,
+    DROP COLUMN IF EXISTS next_attempt_at;
+-- +goose StatementEnd
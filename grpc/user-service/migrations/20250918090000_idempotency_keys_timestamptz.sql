@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- expires_at was written in UTC, created_at in the session time zone
+ALTER TABLE idempotency_keys
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+-- +goose StatementEnd
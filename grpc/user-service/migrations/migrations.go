@@ -0,0 +1,8 @@
+// Package migrations embeds the goose SQL migrations of the user service so
+// the binary does not depend on its working directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
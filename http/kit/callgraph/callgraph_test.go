@@ -0,0 +1,317 @@
+package callgraph
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParse_DefaultsAndDurations(t *testing.T) {
+	spec, err := Parse([]byte(`
+services:
+  svc-a:
+    routes:
+      /ping:
+        latency: {distribution: normal, mean: 20ms, stddev: 5ms}
+        stages:
+          - calls:
+              - url: http://svc-b:8081/ping
+                repeat: 3
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	route := spec.Services["svc-a"].Routes["/ping"]
+	if route.Latency.Mean != 20*time.Millisecond {
+		t.Errorf("mean = %s, want 20ms", route.Latency.Mean)
+	}
+
+	stage := route.Stages[0]
+	if stage.Mode != Sequential {
+		t.Errorf("mode = %q, want sequential", stage.Mode)
+	}
+	call := stage.Calls[0]
+	if call.Method != http.MethodGet || call.Name != "svc-b:8081/ping" || call.Repeat != 3 {
+		t.Errorf("call defaults = %+v", call)
+	}
+}
+
+func TestParse_RejectsInvalidSpec(t *testing.T) {
+	tests := map[string]string{
+		"error rate":   "services: {a: {routes: {/p: {error_rate: 1.5}}}}",
+		"mode":         "services: {a: {routes: {/p: {stages: [{mode: random, calls: [{url: http://b}]}]}}}}",
+		"missing url":  "services: {a: {routes: {/p: {stages: [{calls: [{name: b}]}]}}}}",
+		"distribution": "services: {a: {routes: {/p: {latency: {distribution: pareto}}}}}",
+	}
+
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(doc)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestLatency_SampleClampsToBounds(t *testing.T) {
+	src := rand.New(rand.NewPCG(1, 2))
+	l := Latency{Distribution: Normal, Mean: 10 * time.Millisecond, StdDev: 50 * time.Millisecond, Min: 5 * time.Millisecond, Max: 15 * time.Millisecond}
+
+	for range 1000 {
+		if d := l.Sample(src); d < l.Min || d > l.Max {
+			t.Fatalf("sample %s outside [%s, %s]", d, l.Min, l.Max)
+		}
+	}
+}
+
+func TestHandler_FansOutInParallel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var hits atomic.Int32
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer downstream.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	exec := New(downstream.Client(), WithTracerProvider(tp))
+
+	spec, err := Parse([]byte(`
+services:
+  svc-a:
+    routes:
+      /ping:
+        stages:
+          - mode: parallel
+            calls:
+              - name: fan
+                url: ` + downstream.URL + `
+                repeat: 4
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	router := gin.New()
+	exec.Register(router, spec.Service("svc-a", Service{}))
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if hits.Load() != 4 {
+		t.Errorf("downstream hits = %d, want 4", hits.Load())
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("parallel stage took %s, calls did not overlap", elapsed)
+	}
+	if got := len(recorder.Ended()); got != 4 {
+		t.Errorf("call spans = %d, want 4", got)
+	}
+}
+
+func TestHandler_InjectedFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var hits atomic.Int32
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer downstream.Close()
+
+	exec := New(downstream.Client())
+	call := Call{Name: "b", Method: http.MethodGet, URL: downstream.URL, Repeat: 1}
+
+	router := gin.New()
+	router.GET("/route", exec.Handler(Route{ErrorRate: 1, Stages: []Stage{{Mode: Sequential, Calls: []Call{call}}}}))
+	call.ErrorRate = 1
+	router.GET("/edge", exec.Handler(Route{Stages: []Stage{{Mode: Sequential, Calls: []Call{call}}}}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/route", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("route failure status = %d, want 500", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/edge", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("edge failure status = %d, want 502", w.Code)
+	}
+
+	if hits.Load() != 0 {
+		t.Errorf("downstream hits = %d, want 0", hits.Load())
+	}
+}
+
+func TestHandler_PropagatesDownstreamFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		downstream http.HandlerFunc
+		call       Call
+		wantStatus int
+		wantHits   int32
+	}{
+		{
+			name:       "retries until success",
+			downstream: failFirst(2, http.StatusServiceUnavailable),
+			call:       Call{Retry: Retry{MaxAttempts: 3}},
+			wantStatus: http.StatusOK,
+			wantHits:   3,
+		},
+		{
+			name:       "retries exhausted",
+			downstream: failFirst(5, http.StatusServiceUnavailable),
+			call:       Call{Retry: Retry{MaxAttempts: 3}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantHits:   3,
+		},
+		{
+			name:       "client error is not retried",
+			downstream: failFirst(5, http.StatusNotFound),
+			call:       Call{Retry: Retry{MaxAttempts: 3}},
+			wantStatus: http.StatusBadGateway,
+			wantHits:   1,
+		},
+		{
+			name: "timeout",
+			downstream: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			call:       Call{Timeout: 20 * time.Millisecond, Retry: Retry{MaxAttempts: 1}},
+			wantStatus: http.StatusGatewayTimeout,
+			wantHits:   1,
+		},
+		{
+			name:       "optional failure is tolerated",
+			downstream: failFirst(5, http.StatusInternalServerError),
+			call:       Call{Optional: true, Retry: Retry{MaxAttempts: 1}},
+			wantStatus: http.StatusOK,
+			wantHits:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				tt.downstream(w, r)
+			}))
+			defer downstream.Close()
+
+			call := tt.call
+			call.Name, call.Method, call.URL, call.Repeat = "b", http.MethodGet, downstream.URL, 1
+			call.Retry.BackoffInitial, call.Retry.BackoffMax = time.Millisecond, time.Millisecond
+
+			router := gin.New()
+			router.GET("/ping", New(downstream.Client()).Handler(Route{Stages: []Stage{{Mode: Sequential, Calls: []Call{call}}}}))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if hits.Load() != tt.wantHits {
+				t.Errorf("downstream hits = %d, want %d", hits.Load(), tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestHandler_ChainReportsDownstreamStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	// c always fails, b calls c, a calls b: the failure surfaces at a as a
+	// 502 carrying b's answer.
+	c := httptest.NewServer(failFirst(100, http.StatusInternalServerError))
+	defer c.Close()
+
+	b := gin.New()
+	b.GET("/ping", New(c.Client()).Handler(Route{Stages: []Stage{{Mode: Sequential, Calls: []Call{
+		{Name: "c", Method: http.MethodGet, URL: c.URL, Repeat: 1, Retry: Retry{MaxAttempts: 1}},
+	}}}}))
+	bServer := httptest.NewServer(b)
+	defer bServer.Close()
+
+	a := gin.New()
+	a.GET("/ping", New(bServer.Client(), WithTracerProvider(tp)).Handler(Route{Stages: []Stage{{Mode: Sequential, Calls: []Call{
+		{Name: "b", Method: http.MethodGet, URL: bServer.URL + "/ping", Repeat: 1, Retry: Retry{MaxAttempts: 1}},
+	}}}}))
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+
+	var body struct {
+		Error            string `json:"error"`
+		DownstreamStatus int    `json:"downstream_status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.DownstreamStatus != http.StatusBadGateway {
+		t.Errorf("downstream_status = %d, want 502", body.DownstreamStatus)
+	}
+	if body.Error == "" {
+		t.Error("error message is empty")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("call span status = %v, want error", spans[0].Status().Code)
+	}
+	var found bool
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "callgraph.downstream.status_code" && kv.Value == attribute.IntValue(http.StatusBadGateway) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("call span lacks the downstream status code: %v", spans[0].Attributes())
+	}
+}
+
+// failFirst answers status for the first n requests and 200 afterwards.
+func failFirst(n int32, status int) http.HandlerFunc {
+	var seen atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if seen.Add(1) <= n {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":"boom"}`))
+		}
+	}
+}
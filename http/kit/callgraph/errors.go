@@ -0,0 +1,74 @@
+package callgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInjected is returned for failures produced by an error_rate roll.
+var ErrInjected = errors.New("injected failure")
+
+// StatusError is a downstream answer outside the 2xx range. Message carries
+// the "error" field of the downstream JSON body when there is one.
+type StatusError struct {
+	Call       string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("call %s: status %d: %s", e.Call, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("call %s: status %d", e.Call, e.StatusCode)
+}
+
+func newStatusError(call string, resp *http.Response, body []byte) *StatusError {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	return &StatusError{Call: call, StatusCode: resp.StatusCode, Message: payload.Error}
+}
+
+// retryable reports whether another attempt may succeed. Errors caused by
+// the caller's own context are never retried.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return true
+}
+
+// statusFor maps a downstream failure to the status the route answers with.
+func statusFor(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return http.StatusServiceUnavailable
+		case http.StatusGatewayTimeout:
+			return http.StatusGatewayTimeout
+		}
+		return http.StatusBadGateway
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,267 @@
+package callgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"kit/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "kit/callgraph"
+
+type Executor struct {
+	client *http.Client
+	src    Source
+	tracer trace.Tracer
+}
+
+type Option func(*Executor)
+
+func WithSource(src Source) Option {
+	return func(e *Executor) { e.src = src }
+}
+
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(e *Executor) { e.tracer = tp.Tracer(tracerName) }
+}
+
+// New returns an executor that sends downstream calls with client, which
+// should be the service's shared instrumented client.
+func New(client *http.Client, opts ...Option) *Executor {
+	e := &Executor{
+		client: client,
+		src:    globalSource{},
+		tracer: otel.Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Register mounts every route of svc on r.
+func (e *Executor) Register(r gin.IRoutes, svc Service) {
+	for path, route := range svc.Routes {
+		r.GET(path, e.Handler(route))
+	}
+}
+
+// Handler serves a route. An injected route failure answers 500 and a failed
+// required call answers 502, 503 or 504 depending on how it failed; both
+// with a JSON error body and the server span marked as failed. Optional call
+// failures are only logged and recorded on their spans.
+func (e *Executor) Handler(route Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		if err := e.process(ctx, route); err != nil {
+			abort(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := e.downstream(ctx, route); err != nil {
+			abort(c, statusFor(err), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	}
+}
+
+func abort(c *gin.Context, status int, err error) {
+	body := gin.H{
+		"error":      err.Error(),
+		"request_id": c.GetString(middleware.RequestIDKey),
+	}
+
+	span := trace.SpanFromContext(c.Request.Context())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		body["downstream_status"] = statusErr.StatusCode
+		span.SetAttributes(attribute.Int("callgraph.downstream.status_code", statusErr.StatusCode))
+	}
+
+	c.AbortWithStatusJSON(status, body)
+}
+
+// process simulates the route's own work and rolls its error rate.
+func (e *Executor) process(ctx context.Context, route Route) error {
+	if d := route.Latency.Sample(e.src); d > 0 {
+		_, span := e.tracer.Start(ctx, "process")
+		err := sleep(ctx, d)
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.roll(route.ErrorRate) {
+		return ErrInjected
+	}
+	return nil
+}
+
+// downstream runs the stages in order and stops at the first stage with a
+// failed required call.
+func (e *Executor) downstream(ctx context.Context, route Route) error {
+	for _, stage := range route.Stages {
+		if err := e.runStage(ctx, stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Executor) runStage(ctx context.Context, stage Stage) error {
+	var calls []Call
+	for _, call := range stage.Calls {
+		for range call.Repeat {
+			calls = append(calls, call)
+		}
+	}
+
+	errs := make([]error, len(calls))
+
+	if stage.Mode == Parallel {
+		var wg sync.WaitGroup
+		for i, call := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = e.call(ctx, call)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i, call := range calls {
+			errs[i] = e.call(ctx, call)
+			if errs[i] != nil && !call.Optional {
+				break
+			}
+		}
+	}
+
+	var required []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if calls[i].Optional {
+			log.Printf("optional call failed: %v", err)
+			continue
+		}
+		required = append(required, err)
+	}
+	return errors.Join(required...)
+}
+
+// call runs one edge under its own span, retrying with jittered exponential
+// backoff. Every attempt gets its own timeout and its own client span.
+func (e *Executor) call(ctx context.Context, call Call) (err error) {
+	ctx, span := e.tracer.Start(ctx, "call "+call.Name, trace.WithAttributes(
+		attribute.String("callgraph.call.name", call.Name),
+		attribute.String("callgraph.call.url", call.URL),
+		attribute.Bool("callgraph.call.optional", call.Optional),
+	))
+
+	attempt := 1
+	defer func() {
+		span.SetAttributes(attribute.Int("callgraph.call.attempts", attempt))
+		if err != nil {
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				span.SetAttributes(attribute.Int("callgraph.downstream.status_code", statusErr.StatusCode))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	for ; ; attempt++ {
+		err = e.attempt(ctx, span, call)
+		if err == nil || attempt >= call.Retry.MaxAttempts || !retryable(ctx, err) {
+			return err
+		}
+
+		delay := e.backoff(call.Retry, attempt)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("callgraph.call.attempt", attempt),
+			attribute.String("callgraph.retry.delay", delay.String()),
+			attribute.String("error", err.Error()),
+		))
+		if serr := sleep(ctx, delay); serr != nil {
+			return err
+		}
+	}
+}
+
+func (e *Executor) attempt(ctx context.Context, span trace.Span, call Call) error {
+	if call.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.Timeout)
+		defer cancel()
+	}
+
+	if d := call.Latency.Sample(e.src); d > 0 {
+		span.AddEvent("injected latency", trace.WithAttributes(attribute.String("callgraph.latency", d.String())))
+		if err := sleep(ctx, d); err != nil {
+			return fmt.Errorf("call %s: %w", call.Name, err)
+		}
+	}
+
+	if e.roll(call.ErrorRate) {
+		return fmt.Errorf("call %s: %w", call.Name, ErrInjected)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, call.Method, call.URL, nil)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", call.Name, err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", call.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if err != nil {
+		return fmt.Errorf("call %s: read body: %w", call.Name, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(call.Name, resp, body)
+	}
+	return nil
+}
+
+// backoff doubles the delay per attempt up to BackoffMax and keeps a random
+// half of it so that fanned-out retries do not line up.
+func (e *Executor) backoff(r Retry, attempt int) time.Duration {
+	d := r.BackoffInitial << (attempt - 1)
+	if d > r.BackoffMax || d <= 0 {
+		d = r.BackoffMax
+	}
+	return d/2 + time.Duration(e.src.Float64()*float64(d/2))
+}
+
+func (e *Executor) roll(rate float64) bool {
+	return rate > 0 && e.src.Float64() < rate
+}
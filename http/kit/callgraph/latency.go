@@ -0,0 +1,100 @@
+package callgraph
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+type Distribution string
+
+const (
+	Fixed       Distribution = "fixed"
+	Uniform     Distribution = "uniform"
+	Normal      Distribution = "normal"
+	Exponential Distribution = "exponential"
+)
+
+// Latency is an artificial delay. Fixed uses Mean, Uniform draws between Min
+// and Max, Normal uses Mean and StdDev and Exponential uses Mean. Samples are
+// clamped to [Min, Max] when those are set. The zero value adds no delay.
+type Latency struct {
+	Distribution Distribution  `yaml:"distribution"`
+	Mean         time.Duration `yaml:"mean"`
+	StdDev       time.Duration `yaml:"stddev"`
+	Min          time.Duration `yaml:"min"`
+	Max          time.Duration `yaml:"max"`
+}
+
+// Source is the randomness the executor draws from. *rand.Rand satisfies it,
+// which lets tests use a seeded generator.
+type Source interface {
+	Float64() float64
+	NormFloat64() float64
+	ExpFloat64() float64
+}
+
+type globalSource struct{}
+
+func (globalSource) Float64() float64     { return rand.Float64() }
+func (globalSource) NormFloat64() float64 { return rand.NormFloat64() }
+func (globalSource) ExpFloat64() float64  { return rand.ExpFloat64() }
+
+func (l Latency) Sample(src Source) time.Duration {
+	var d time.Duration
+
+	switch l.Distribution {
+	case "":
+		return 0
+	case Fixed:
+		d = l.Mean
+	case Uniform:
+		d = l.Min + time.Duration(src.Float64()*float64(l.Max-l.Min))
+	case Normal:
+		d = l.Mean + time.Duration(src.NormFloat64()*float64(l.StdDev))
+	case Exponential:
+		d = time.Duration(src.ExpFloat64() * float64(l.Mean))
+	}
+
+	if d < l.Min {
+		d = l.Min
+	}
+	if l.Max > 0 && d > l.Max {
+		d = l.Max
+	}
+	return max(d, 0)
+}
+
+func (l Latency) validate() error {
+	switch l.Distribution {
+	case "", Fixed, Normal, Exponential:
+	case Uniform:
+		if l.Max < l.Min {
+			return fmt.Errorf("uniform latency: max %s is below min %s", l.Max, l.Min)
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+
+	if l.Mean < 0 || l.StdDev < 0 || l.Min < 0 || l.Max < 0 {
+		return fmt.Errorf("latency durations must not be negative")
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
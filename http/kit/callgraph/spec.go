@@ -0,0 +1,215 @@
+// Package callgraph drives the downstream calls of the demo services from a
+// declarative spec, so fan-out, latency and failure patterns can be changed
+// without touching code.
+package callgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvFile names a YAML file holding the spec.
+	EnvFile = "CALLGRAPH_FILE"
+	// EnvInline holds the spec itself and takes precedence over EnvFile.
+	EnvInline = "CALLGRAPH"
+)
+
+// Spec describes the routes of every service in the graph, keyed by service
+// name, so one file can be shared by the whole stack.
+type Spec struct {
+	Services map[string]Service `yaml:"services"`
+}
+
+type Service struct {
+	Routes map[string]Route `yaml:"routes"`
+}
+
+// Route is what a service does when one of its endpoints is hit: simulated
+// processing time and failure rate, then its downstream calls stage by stage.
+type Route struct {
+	Latency   Latency `yaml:"latency"`
+	ErrorRate float64 `yaml:"error_rate"`
+	Stages    []Stage `yaml:"stages"`
+}
+
+type Mode string
+
+const (
+	Sequential Mode = "sequential"
+	Parallel   Mode = "parallel"
+)
+
+// Stage is a group of calls run one after another or all at once. Stages
+// always run in order.
+type Stage struct {
+	Mode  Mode   `yaml:"mode"`
+	Calls []Call `yaml:"calls"`
+}
+
+// Call is an edge of the graph. Latency and ErrorRate are injected on the
+// caller side before each attempt goes out, Repeat fans the same call out.
+// A failed call fails the route unless it is Optional.
+type Call struct {
+	Name      string        `yaml:"name"`
+	Method    string        `yaml:"method"`
+	URL       string        `yaml:"url"`
+	Repeat    int           `yaml:"repeat"`
+	Latency   Latency       `yaml:"latency"`
+	ErrorRate float64       `yaml:"error_rate"`
+	Optional  bool          `yaml:"optional"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Retry     Retry         `yaml:"retry"`
+}
+
+// Retry controls how transport errors, injected failures and 429/502/503/504
+// answers are retried. MaxAttempts counts the first try.
+type Retry struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	BackoffInitial time.Duration `yaml:"backoff_initial"`
+	BackoffMax     time.Duration `yaml:"backoff_max"`
+}
+
+const (
+	defaultTimeout        = 2 * time.Second
+	defaultMaxAttempts    = 3
+	defaultBackoffInitial = 50 * time.Millisecond
+	defaultBackoffMax     = time.Second
+)
+
+// FromEnv loads the spec named by the CALLGRAPH or CALLGRAPH_FILE variables.
+// It returns an empty spec when neither is set.
+func FromEnv() (*Spec, error) {
+	if inline := os.Getenv(EnvInline); inline != "" {
+		return Parse([]byte(inline))
+	}
+	if path := os.Getenv(EnvFile); path != "" {
+		return LoadFile(path)
+	}
+	return &Spec{}, nil
+}
+
+func LoadFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read call graph: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes, defaults and validates a YAML spec.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse call graph: %w", err)
+	}
+
+	for name, svc := range spec.Services {
+		for path, route := range svc.Routes {
+			route.setDefaults()
+			if err := route.validate(); err != nil {
+				return nil, fmt.Errorf("call graph %s %s: %w", name, path, err)
+			}
+			svc.Routes[path] = route
+		}
+	}
+
+	return &spec, nil
+}
+
+// Service returns the routes configured for name, or fallback when the spec
+// does not mention the service.
+func (s *Spec) Service(name string, fallback Service) Service {
+	if svc, ok := s.Services[name]; ok && len(svc.Routes) > 0 {
+		return svc
+	}
+	for path, route := range fallback.Routes {
+		route.setDefaults()
+		fallback.Routes[path] = route
+	}
+	return fallback
+}
+
+func (r *Route) setDefaults() {
+	for i := range r.Stages {
+		stage := &r.Stages[i]
+		if stage.Mode == "" {
+			stage.Mode = Sequential
+		}
+		for j := range stage.Calls {
+			call := &stage.Calls[j]
+			if call.Method == "" {
+				call.Method = http.MethodGet
+			}
+			if call.Repeat == 0 {
+				call.Repeat = 1
+			}
+			if call.Timeout == 0 {
+				call.Timeout = defaultTimeout
+			}
+			if call.Retry.MaxAttempts == 0 {
+				call.Retry.MaxAttempts = defaultMaxAttempts
+			}
+			if call.Retry.BackoffInitial == 0 {
+				call.Retry.BackoffInitial = defaultBackoffInitial
+			}
+			if call.Retry.BackoffMax == 0 {
+				call.Retry.BackoffMax = defaultBackoffMax
+			}
+			if call.Name == "" {
+				if u, err := url.Parse(call.URL); err == nil && u.Host != "" {
+					call.Name = u.Host + u.Path
+				} else {
+					call.Name = call.URL
+				}
+			}
+		}
+	}
+}
+
+func (r Route) validate() error {
+	if err := validateRate(r.ErrorRate); err != nil {
+		return err
+	}
+	if err := r.Latency.validate(); err != nil {
+		return err
+	}
+
+	for i, stage := range r.Stages {
+		if stage.Mode != Sequential && stage.Mode != Parallel {
+			return fmt.Errorf("stage %d: unknown mode %q", i, stage.Mode)
+		}
+		for _, call := range stage.Calls {
+			if call.URL == "" {
+				return fmt.Errorf("stage %d: call %q has no url", i, call.Name)
+			}
+			if call.Repeat < 0 {
+				return fmt.Errorf("stage %d: call %q: repeat must not be negative", i, call.Name)
+			}
+			if call.Timeout < 0 || call.Retry.MaxAttempts < 0 || call.Retry.BackoffInitial < 0 || call.Retry.BackoffMax < 0 {
+				return fmt.Errorf("stage %d: call %q: timeout and retry settings must not be negative", i, call.Name)
+			}
+			if err := validateRate(call.ErrorRate); err != nil {
+				return fmt.Errorf("stage %d: call %q: %w", i, call.Name, err)
+			}
+			if err := call.Latency.validate(); err != nil {
+				return fmt.Errorf("stage %d: call %q: %w", i, call.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return errors.New("error_rate must be between 0 and 1")
+	}
+	return nil
+}
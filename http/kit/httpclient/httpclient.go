@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+type Config struct {
+	// Timeout bounds a whole request including reading the body.
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:             10 * time.Second,
+		DialTimeout:         2 * time.Second,
+		TLSHandshakeTimeout: 2 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// New returns a client with a pooled transport wrapped by otelhttp, so every
+// outgoing request gets a client span and carries the trace context. Build it
+// once per service and share it.
+func New(cfg Config) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: otelhttp.NewTransport(transport),
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDKey    = "request_id"
+)
+
+// Default returns the middleware every demo service installs, in order:
+// tracing, request id and recovery. Recovery runs inside the server span so
+// panics are recorded on it.
+func Default(serviceName string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(serviceName),
+		RequestID(),
+		Recovery(),
+	}
+}
+
+// RequestID reuses the incoming X-Request-ID or generates one, echoes it on
+// the response and records it on the server span.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request.id", id))
+
+		c.Next()
+	}
+}
+
+// Recovery turns panics into a 500 JSON response and marks the span as failed
+// with the panic and its stack trace.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+
+			err := fmt.Errorf("panic: %v", r)
+			stack := string(debug.Stack())
+
+			span := trace.SpanFromContext(c.Request.Context())
+			span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", stack)))
+			span.SetStatus(codes.Error, err.Error())
+
+			log.Printf("recovered from %v\n%s", err, stack)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":      "internal server error",
+				"request_id": c.GetString(RequestIDKey),
+			})
+		}()
+
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
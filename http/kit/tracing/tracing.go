@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// Endpoint is the base URL of the OTLP/HTTP collector, e.g.
+	// http://jaeger:4318. Spans go to its /v1/traces path, and TLS is used
+	// for https URLs.
+	Endpoint string
+	// SampleRatio is the share of root traces recorded, between 0 and 1.
+	SampleRatio float64
+}
+
+// ConfigFromEnv reads the standard OTEL_* variables, falling back to the given
+// service name and the docker-compose Jaeger endpoint.
+func ConfigFromEnv(serviceName string) Config {
+	cfg := Config{
+		ServiceName:    getenv("OTEL_SERVICE_NAME", serviceName),
+		ServiceVersion: getenv("SERVICE_VERSION", "1.0.0"),
+		Environment:    getenv("DEPLOYMENT_ENVIRONMENT", getenv("APP_ENV", "development")),
+		Endpoint:       getenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://jaeger:4318"),
+		SampleRatio:    1,
+	}
+
+	if v, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil && v >= 0 && v <= 1 {
+		cfg.SampleRatio = v
+	}
+
+	return cfg
+}
+
+// NewTracerProvider creates an OTLP/HTTP tracer provider and installs it, with
+// the TraceContext and Baggage propagators, as the global one.
+func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	endpoint, err := tracesURL(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp, nil
+}
+
+// tracesURL appends the OTLP traces path to the collector base URL, the way
+// OTEL_EXPORTER_OTLP_ENDPOINT is defined.
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("otlp endpoint: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("otlp endpoint %q: want an http or https URL such as http://jaeger:4318", endpoint)
+	}
+	return u.JoinPath("v1", "traces").String(), nil
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+
+	cfg := ConfigFromEnv("SERVICE_A")
+	if cfg.ServiceName != "SERVICE_A" || cfg.Environment != "staging" || cfg.SampleRatio != 0.25 {
+		t.Fatalf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	tp, err := NewTracerProvider(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewTracerProvider() error = %v", err)
+	}
+	_ = tp.Shutdown(context.Background())
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "http://jaeger:4318", want: "http://jaeger:4318/v1/traces"},
+		{endpoint: "https://collector.example.com/otlp/", want: "https://collector.example.com/otlp/v1/traces"},
+		{endpoint: "jaeger:4318", wantErr: true},
+		{endpoint: "grpc://jaeger:4317", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tracesURL(tt.endpoint)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("tracesURL(%q) = %q, %v", tt.endpoint, got, err)
+		}
+	}
+}
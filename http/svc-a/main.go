@@ -3,52 +3,59 @@ package main
 import (
 	"context"
 	"log"
-	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"svc-a/pkg/tracing"
+	"kit/callgraph"
+	"kit/httpclient"
+	"kit/middleware"
+	"kit/server"
+	"kit/tracing"
 
 	"github.com/gin-gonic/gin"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// defaultRoutes is used when the call graph spec does not mention svc-a.
+var defaultRoutes = callgraph.Service{Routes: map[string]callgraph.Route{
+	"/ping": {
+		Stages: []callgraph.Stage{{
+			Calls: []callgraph.Call{{URL: "http://svc-b:8081/ping"}},
+		}},
+	},
+}}
+
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// tracing
-	tp, err := tracing.NewOTLPTracerProvider(ctx, "SERVICE_A", "jaeger:4318")
+	tp, err := tracing.NewTracerProvider(ctx, tracing.ConfigFromEnv("SERVICE_A"))
 	if err != nil {
 		log.Fatalf("failed to create tracer provider: %v", err)
 	}
 
 	defer func() {
-		_ = tp.Shutdown(ctx)
+		_ = tp.Shutdown(context.Background())
 	}()
 
-	// gin server
-	router := gin.Default()
-
-	router.Use(otelgin.Middleware("SERVICE_A"))
+	// call graph
+	spec, err := callgraph.FromEnv()
+	if err != nil {
+		log.Fatalf("failed to load call graph: %v", err)
+	}
 
-	router.GET("/ping", func(c *gin.Context) {
-		callServiceB(c.Request.Context())
-		c.JSON(200, gin.H{"message": "pong"})
-	})
+	// shared instrumented client
+	client := httpclient.New(httpclient.DefaultConfig())
 
-	router.Run(":8080")
-}
+	// gin server
+	router := gin.New()
 
-func callServiceB(c context.Context) {
-	client := http.Client{
-		Transport: otelhttp.NewTransport(http.DefaultTransport),
-	}
+	router.Use(middleware.Default("SERVICE_A")...)
 
-	req, _ := http.NewRequestWithContext(c, "GET", "http://svc-b:8081/ping", nil)
+	callgraph.New(client).Register(router, spec.Service("svc-a", defaultRoutes))
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("failed to call service b: %v", err)
-		return
+	if err := server.Run(ctx, ":8080", router, 10*time.Second); err != nil {
+		log.Fatalf("server error: %v", err)
 	}
-	defer resp.Body.Close()
 }
@@ -3,34 +3,55 @@ package main
 import (
 	"context"
 	"log"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"svc-c/pkg/tracing"
+	"kit/callgraph"
+	"kit/httpclient"
+	"kit/middleware"
+	"kit/server"
+	"kit/tracing"
 
 	"github.com/gin-gonic/gin"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// defaultRoutes is used when the call graph spec does not mention svc-c.
+var defaultRoutes = callgraph.Service{Routes: map[string]callgraph.Route{
+	"/ping": {},
+}}
+
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// tracing
-	tp, err := tracing.NewOTLPTracerProvider(ctx, "SERVICE_C", "jaeger:4318")
+	tp, err := tracing.NewTracerProvider(ctx, tracing.ConfigFromEnv("SERVICE_C"))
 	if err != nil {
 		log.Fatalf("failed to create tracer provider: %v", err)
 	}
 
 	defer func() {
-		_ = tp.Shutdown(ctx)
+		_ = tp.Shutdown(context.Background())
 	}()
 
+	// call graph
+	spec, err := callgraph.FromEnv()
+	if err != nil {
+		log.Fatalf("failed to load call graph: %v", err)
+	}
+
+	// shared instrumented client
+	client := httpclient.New(httpclient.DefaultConfig())
+
 	// gin server
-	router := gin.Default()
+	router := gin.New()
 
-	router.Use(otelgin.Middleware("SERVICE_C"))
+	router.Use(middleware.Default("SERVICE_C")...)
 
-	router.GET("/ping", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "pong"})
-	})
+	callgraph.New(client).Register(router, spec.Service("svc-c", defaultRoutes))
 
-	router.Run(":8082")
+	if err := server.Run(ctx, ":8082", router, 10*time.Second); err != nil {
+		log.Fatalf("server error: %v", err)
+	}
 }